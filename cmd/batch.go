@@ -0,0 +1,804 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"training-practice/internal/fileutil"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	batchMode          string        // 操作模式
+	batchSrc           string        // 源目录
+	batchDest          string        // 目标目录
+	batchPrefix        string        // 重命名前缀
+	batchSuffix        string        // 重命名后缀
+	batchWorkers       int           // 并发Worker数
+	batchMaxRetries    int           // 最大重试次数
+	batchRetryInterval time.Duration // 第一次重试前的等待时间
+	batchRetryFactor   float64       // 重试等待时间的倍数
+	batchRetryMaxDelay time.Duration // 单次重试等待时间上限
+	batchRetryJitter   float64       // 重试等待时间的随机比例
+	batchRetryBudget   int           // 全部文件合计的最多重试次数
+	batchRetryWait     time.Duration // 全部文件合计的最长重试等待时间
+	batchOnTrip        string        // 熔断时的处理方式
+	batchTripFallback  string        // 标准输入不是终端、无法交互询问时熔断的处理方式
+	batchSpaceCheck    string        // 启动前检查目标磁盘空间的方式
+	batchSpaceMargin   string        // 目标磁盘的安全余量
+	batchPreserve      []string      // 复制时保留的元数据
+	batchTripThreshold int           // 熔断阈值
+	batchTripWindow    time.Duration // 熔断统计时间窗口
+	batchVerify        bool          // 严格校验
+	batchAlgorithms    []string      // 哈希算法
+	batchManifest      string        // 校验清单输出路径
+	batchCompare       string        // 同步模式的比较方式
+	batchDelete        bool          // 同步模式下删除多余文件
+	batchJournal       string        // 断点文件路径
+	batchResume        bool          // 断点续作
+	batchUndoLog       string        // 撤销日志路径
+	batchDryRun        bool          // 只生成执行计划
+	batchPlanFormat    string        // 执行计划输出格式
+	batchSavePlan      string        // 执行计划保存路径
+	batchPlan          string        // 按已保存的执行计划执行
+	batchOnConflict    string        // 目标已存在时的处理策略
+	batchTemplate      string        // 重命名模板
+	batchPreview       int           // 预览前N个文件的新名称
+	batchRegex         string        // 正则替换表达式
+	batchRegexScope    string        // 正则替换的作用范围
+	batchTransforms    []string      // 文件名变换链
+	batchNumber        bool          // 按序号重命名
+	batchNumberSort    string        // 序号的排序方式
+	batchNumberStart   int           // 起始序号
+	batchNumberStep    int           // 序号步长
+	batchNumberPad     int           // 序号补零位数
+	batchInclude       []string      // 包含规则
+	batchExclude       []string      // 排除规则
+	batchMinSize       string        // 最小文件大小
+	batchMaxSize       string        // 最大文件大小
+	batchAfter         string        // 只处理此时间及之后修改的文件
+	batchBefore        string        // 只处理此时间之前修改的文件
+	batchSkipHidden    bool          // 跳过隐藏文件和目录
+	batchIgnoreFile    string        // .gitignore格式的忽略文件
+	batchFilterFile    string        // 读取筛选配置文件
+	batchSaveFilter    string        // 保存筛选配置文件
+)
+
+// shutdownTimeout 收到退出信号后等待已派发任务结束的最长时间
+const shutdownTimeout = 5 * time.Second
+
+// batchCmd 目录级批量处理（无界面运行Worker Pool）
+var batchCmd = &cobra.Command{
+	Use:   "batch",
+	Short: "对整个目录执行批量处理（MD5/重命名/复制/移动/同步）",
+	Long: `递归扫描源目录，使用与界面相同的Worker Pool并发处理所有文件，
+适用于没有图形界面的服务器、定时任务和CI环境。
+
+处理过程中会定期把已完成和失败的任务写入断点文件（默认位于用户缓存目录），
+中断或崩溃后使用相同参数加 --resume 重新运行，即可跳过已完成的文件、重新处理失败的文件。
+
+重命名、移动等会改变源文件位置的模式会写出撤销日志，可使用 filetool undo 还原。
+
+使用 --dry-run 只生成执行计划（每个文件的目标路径、总大小和冲突），不修改任何文件；
+配合 --save-plan 保存计划后，可用 --plan 严格按预览的计划执行。
+
+重命名类模式可使用 --template 指定Go模板形式的重命名规则（替代--prefix/--suffix），
+模板的结果为新文件名，可包含 / 以放入子目录；使用 --preview N 预览前N个文件的新名称。
+
+也可使用 --regex 's/IMG_(\d+)/photo-$1/i' 按正则查找替换（i忽略大小写，g替换所有匹配），
+不匹配的文件会被跳过；--regex-scope 指定作用于文件名、扩展名或相对路径。
+
+--transform 在以上规则之后按顺序对文件名执行变换，例如 --transform nfc,strip_illegal,truncate:100，
+避免与Windows、macOS用户共享文件时出现非法或无法显示的文件名。
+
+--number 把文件重命名为 前缀+序号+后缀+扩展名，先按 --number-sort 排序文件列表再分配序号，
+结果与并发处理的完成顺序无关；--number-start/--number-step/--number-pad 调整起始值、步长和补零位数。
+与 --template 同时使用时只决定 {{.Seq}} 和 {{.Num}} 的值。新名称与其他文件的原名称互换或链式占用时，
+会先把被占用的文件移到暂存路径再重命名，不会互相覆盖。
+
+扫描源目录时可用 --include/--exclude 通配符（如 '*.log'、'**/subdir/**'，匹配目录时作用于其中所有文件）、
+--min-size/--max-size、--modified-after/--modified-before、--skip-hidden 和 .gitignore 格式的 --ignore-file 筛选文件，
+结束时会统计每个筛选条件排除的文件数。--save-filter 把筛选条件保存为JSON文件，之后用 --filter-file 加载
+（命令行参数覆盖文件中的设置，包含/排除规则追加到文件中的规则之后）。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// 指定任一序号参数即视为启用序号重命名
+		for _, name := range []string{"number-sort", "number-start", "number-step", "number-pad"} {
+			batchNumber = batchNumber || cmd.Flags().Changed(name)
+		}
+		if batchPreview > 0 {
+			if err := runBatchPreview(); err != nil {
+				fmt.Fprintf(os.Stderr, "预览失败: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		if batchDryRun || batchSavePlan != "" {
+			if err := runBatchPlan(); err != nil {
+				fmt.Fprintf(os.Stderr, "生成执行计划失败: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		summary, err := runBatch()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "批量处理失败: %v\n", err)
+			os.Exit(1)
+		}
+		if summary.Failed > 0 || summary.Aborted || summary.Interrupted || summary.DeleteErr != nil || summary.JournalErr != nil || summary.UndoErr != nil ||
+			len(summary.Stranded) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(batchCmd)
+
+	// 枚举已注册的操作模式
+	var names []string
+	batchCmd.Long += "\n\n操作模式："
+	for _, op := range fileutil.Operators() {
+		info := op.Info()
+		names = append(names, info.Name)
+		batchCmd.Long += fmt.Sprintf("\n  %-12s %s", info.Name, info.Label)
+	}
+	batchCmd.Long += "\n\n文件名变换："
+	for _, name := range fileutil.Transforms() {
+		batchCmd.Long += fmt.Sprintf("\n  %-20s %s", name, fileutil.TransformLabel(name))
+	}
+	batchCmd.Long += "\n\n重命名模板字段和函数："
+	for _, field := range fileutil.TemplateFields() {
+		batchCmd.Long += "\n  " + field
+	}
+
+	// 添加参数
+	batchCmd.Flags().StringVarP(&batchMode, "mode", "m", "md5", fmt.Sprintf("操作模式（可选：%s）", strings.Join(names, "/")))
+	batchCmd.Flags().StringVarP(&batchSrc, "src", "s", "", "源目录（未使用--plan时必填）")
+	batchCmd.Flags().StringVarP(&batchDest, "dest", "d", "", "目标目录（复制/移动类模式必填）")
+	batchCmd.Flags().StringVar(&batchPrefix, "prefix", "", "重命名前缀")
+	batchCmd.Flags().StringVar(&batchSuffix, "suffix", "", "重命名后缀")
+	batchCmd.Flags().StringVarP(&batchTemplate, "template", "t", "", `重命名模板，例如 "{{.ModTime.Format \"20060102\"}}_{{pad .Seq 3}}{{.Ext}}"`)
+	batchCmd.Flags().IntVar(&batchPreview, "preview", 0, "只预览前N个文件的新名称，不修改任何文件")
+	batchCmd.Flags().StringVarP(&batchRegex, "regex", "r", "", `正则替换表达式 s/正则/替换内容/标志，例如 's/IMG_(\d+)/photo-$1/i'`)
+	var scopes []string
+	for _, scope := range fileutil.RegexScopes() {
+		scopes = append(scopes, fmt.Sprintf("%s(%s)", scope, scope.Label()))
+	}
+	batchCmd.Flags().StringSliceVar(&batchTransforms, "transform", nil, "文件名变换链，按顺序执行（可选值见上文，如 nfc,lower,truncate:100）")
+	batchCmd.Flags().BoolVar(&batchNumber, "number", false, "按序号重命名：前缀+序号+后缀+扩展名")
+	batchCmd.Flags().StringVar(&batchNumberSort, "number-sort", fileutil.SortByName,
+		fmt.Sprintf("分配序号前的排序方式（可选：%s）", strings.Join(fileutil.SortModes(), "/")))
+	batchCmd.Flags().IntVar(&batchNumberStart, "number-start", 1, "起始序号")
+	batchCmd.Flags().IntVar(&batchNumberStep, "number-step", 1, "序号步长")
+	batchCmd.Flags().IntVar(&batchNumberPad, "number-pad", 0, "序号补零位数（0表示按最大序号的位数自动补零）")
+	batchCmd.Flags().StringSliceVar(&batchInclude, "include", nil, "只处理匹配任一通配符的文件（如 '*.jpg,*.png'）")
+	batchCmd.Flags().StringSliceVar(&batchExclude, "exclude", nil, "排除匹配通配符的文件或目录（如 '*.log,**/cache/**'）")
+	batchCmd.Flags().StringVar(&batchMinSize, "min-size", "", "只处理不小于此大小的文件（如 10K、1.5M）")
+	batchCmd.Flags().StringVar(&batchMaxSize, "max-size", "", "只处理不大于此大小的文件（如 100M、2G）")
+	batchCmd.Flags().StringVar(&batchAfter, "modified-after", "", "只处理此时间及之后修改的文件（如 2024-01-31、2024-01-31 08:00、7d）")
+	batchCmd.Flags().StringVar(&batchBefore, "modified-before", "", "只处理此时间之前修改的文件（格式同 --modified-after）")
+	batchCmd.Flags().BoolVar(&batchSkipHidden, "skip-hidden", false, "跳过以.开头的隐藏文件和目录")
+	batchCmd.Flags().StringVar(&batchIgnoreFile, "ignore-file", "", ".gitignore格式的忽略文件（相对路径相对于源目录）")
+	batchCmd.Flags().StringVar(&batchFilterFile, "filter-file", "", "读取保存的筛选配置文件（JSON）")
+	batchCmd.Flags().StringVar(&batchSaveFilter, "save-filter", "", "把本次的筛选条件保存为JSON文件")
+	batchCmd.Flags().StringVar(&batchRegexScope, "regex-scope", string(fileutil.RegexScopeName),
+		fmt.Sprintf("正则替换的作用范围（可选：%s）", strings.Join(scopes, "/")))
+	batchCmd.Flags().StringVar(&batchManifest, "manifest", "", "计算哈希模式下输出md5sum/sha256sum格式的校验清单（使用第一个算法）")
+	batchCmd.Flags().IntVarP(&batchWorkers, "workers", "w", 4, "并发Worker数")
+	batchCmd.Flags().IntVar(&batchMaxRetries, "max-retries", 3, "最大重试次数")
+	batchCmd.Flags().DurationVar(&batchRetryInterval, "retry-interval", fileutil.DefaultRetryPolicy.InitialDelay, "第一次重试前的等待时间")
+	batchCmd.Flags().Float64Var(&batchRetryFactor, "retry-multiplier", fileutil.DefaultRetryPolicy.Multiplier, "每次重试后等待时间的倍数（指数退避）")
+	batchCmd.Flags().DurationVar(&batchRetryMaxDelay, "retry-max-delay", fileutil.DefaultRetryPolicy.MaxDelay, "单次重试等待时间的上限（0表示不限）")
+	batchCmd.Flags().Float64Var(&batchRetryJitter, "retry-jitter", fileutil.DefaultRetryPolicy.Jitter, "随机缩短重试等待时间的最大比例（0~1）")
+	batchCmd.Flags().IntVar(&batchRetryBudget, "retry-budget", fileutil.DefaultRetryBudget.MaxRetries, "所有文件合计的最多重试次数（0表示不限）")
+	batchCmd.Flags().DurationVar(&batchRetryWait, "retry-budget-time", fileutil.DefaultRetryBudget.MaxWait, "所有文件合计的最长重试等待时间（0表示不限）")
+	var decisions []string
+	for _, d := range fileutil.TripDecisions() {
+		decisions = append(decisions, fmt.Sprintf("%s(%s)", d, d.Label()))
+	}
+	batchCmd.Flags().StringVar(&batchOnTrip, "on-trip", tripPrompt,
+		fmt.Sprintf("同类错误集中出现或磁盘已满/只读时暂停所有Worker，%s表示交互询问（可选：%s/%s）",
+			tripPrompt, tripPrompt, strings.Join(decisions, "/")))
+	batchCmd.Flags().StringVar(&batchTripFallback, "on-trip-fallback", string(fileutil.TripAbort),
+		fmt.Sprintf("--on-trip为%s但标准输入不是终端（如在脚本或管道中运行）时熔断的处理方式（可选：%s）", tripPrompt, strings.Join(decisions, "/")))
+	batchCmd.Flags().IntVar(&batchTripThreshold, "trip-threshold", fileutil.DefaultBreaker.Threshold, "时间窗口内同类错误达到此数量时熔断（0表示只在磁盘已满/只读时熔断）")
+	batchCmd.Flags().DurationVar(&batchTripWindow, "trip-window", fileutil.DefaultBreaker.Window, "熔断统计错误数量的时间窗口")
+	batchCmd.Flags().StringVar(&batchSpaceCheck, "space-check", fileutil.SpaceCheckFail,
+		fmt.Sprintf("启动前检查目标磁盘空间（需先遍历源目录统计大小，off可跳过以立即开始）：空间不足时拒绝启动/只提示/不检查（可选：%s）", strings.Join(fileutil.SpaceCheckModes(), "/")))
+	batchCmd.Flags().StringVar(&batchSpaceMargin, "space-margin", fileutil.FormatBytes(fileutil.DefaultSpaceMargin), "复制后目标磁盘至少保留的可用空间（如 512M、2G）")
+	batchCmd.Flags().StringSliceVar(&batchPreserve, "preserve", fileutil.DefaultPreserve.Names(),
+		fmt.Sprintf("复制/移动/同步时保留的元数据（可选：%s/all/none）", strings.Join(preserveChoices(), "/")))
+	batchCmd.Flags().BoolVar(&batchVerify, "verify", false, "严格校验：复制后重新读取目标文件比对哈希")
+	batchCmd.Flags().StringVar(&batchCompare, "compare", fileutil.CompareSizeMtime,
+		fmt.Sprintf("同步模式判断文件是否变化的方式（可选：%s）", strings.Join(fileutil.CompareModes(), "/")))
+	batchCmd.Flags().BoolVar(&batchDelete, "delete", false, "同步模式下删除目标目录中源目录已不存在的文件")
+	batchCmd.Flags().StringVar(&batchJournal, "journal", "", "断点文件路径（默认根据模式和目录生成，位于用户缓存目录）")
+	batchCmd.Flags().BoolVar(&batchResume, "resume", false, "断点续作：跳过上次已完成的文件，重新处理失败的文件")
+	batchCmd.Flags().StringVar(&batchUndoLog, "undo-log", "", "重命名/移动模式的撤销日志路径（默认按时间生成，位于用户缓存目录）")
+	var policies []string
+	for _, p := range fileutil.ConflictPolicies() {
+		policies = append(policies, fmt.Sprintf("%s(%s)", p, p.Label()))
+	}
+	batchCmd.Flags().StringVar(&batchOnConflict, "on-conflict", string(fileutil.DefaultConflictPolicy),
+		fmt.Sprintf("目标已存在时的处理策略，默认跳过已有文件、不再覆盖（可选：%s）", strings.Join(policies, "/")))
+	batchCmd.Flags().BoolVarP(&batchDryRun, "dry-run", "n", false, "只生成并输出执行计划，不修改任何文件")
+	batchCmd.Flags().StringVar(&batchPlanFormat, "plan-format", "table", "执行计划输出格式：table/json")
+	batchCmd.Flags().StringVar(&batchSavePlan, "save-plan", "", "把执行计划保存到文件（隐含--dry-run）")
+	batchCmd.Flags().StringVar(&batchPlan, "plan", "", "按已保存的执行计划执行（模式、目录和目标路径取自计划）")
+	batchCmd.Flags().StringSliceVarP(&batchAlgorithms, "algorithms", "a", []string{fileutil.DefaultAlgorithm},
+		fmt.Sprintf("哈希算法，可指定多个并在一次读取中同时计算（可选：%s）", strings.Join(fileutil.HashAlgorithms(), "/")))
+}
+
+// batchConfig 根据命令行参数（或已保存的执行计划）生成调度配置
+func batchConfig() (fileutil.SchedulerConfig, fileutil.Operator, error) {
+	var cfg fileutil.SchedulerConfig
+	if batchPlan != "" {
+		plan, err := fileutil.LoadPlan(batchPlan)
+		if err != nil {
+			return cfg, nil, fmt.Errorf("读取执行计划失败: %w", err)
+		}
+		cfg = plan.Config()
+	} else {
+		if batchSrc == "" {
+			return cfg, nil, fmt.Errorf("需要指定--src（或使用--plan）")
+		}
+		cfg = fileutil.SchedulerConfig{
+			SrcRoot:    batchSrc,
+			DestRoot:   batchDest,
+			Prefix:     batchPrefix,
+			Suffix:     batchSuffix,
+			Template:   batchTemplate,
+			Transforms: batchTransforms,
+			Mode:       batchMode,
+		}
+		if batchRegex != "" {
+			rule, err := fileutil.ParseRegexExpr(batchRegex)
+			if err != nil {
+				return cfg, nil, err
+			}
+			rule.Scope = fileutil.RegexScope(batchRegexScope)
+			cfg.Regex = &rule
+		}
+		filter, err := batchFilter()
+		if err != nil {
+			return cfg, nil, err
+		}
+		cfg.Filter = filter
+		if batchNumber {
+			cfg.Numbering = &fileutil.Numbering{
+				Sort:  batchNumberSort,
+				Start: batchNumberStart,
+				Step:  batchNumberStep,
+				Pad:   batchNumberPad,
+			}
+		}
+	}
+
+	op, ok := fileutil.LookupOperator(cfg.Mode)
+	if !ok {
+		return cfg, nil, fmt.Errorf("不支持的操作模式: %s", cfg.Mode)
+	}
+	if op.Info().NeedsDest && cfg.DestRoot == "" {
+		return cfg, nil, fmt.Errorf("%s模式需要指定--dest", cfg.Mode)
+	}
+	if batchWorkers < 1 {
+		return cfg, nil, fmt.Errorf("并发Worker数必须大于0")
+	}
+
+	handler, err := batchErrorHandler()
+	if err != nil {
+		return cfg, nil, err
+	}
+
+	margin, err := fileutil.ParseSize(batchSpaceMargin)
+	if err != nil {
+		return cfg, nil, err
+	}
+
+	preserve, err := fileutil.ParsePreserve(batchPreserve)
+	if err != nil {
+		return cfg, nil, err
+	}
+
+	cfg.Workers = batchWorkers
+	cfg.ErrorHandler = handler
+	cfg.SpaceCheck = batchSpaceCheck
+	cfg.SpaceMargin = margin
+	cfg.Preserve = preserve
+	cfg.StrictVerify = batchVerify
+	cfg.Algorithms = batchAlgorithms
+	cfg.ManifestPath = batchManifest
+	cfg.Compare = batchCompare
+	cfg.Delete = batchDelete
+	cfg.Resume = batchResume
+	cfg.OnConflict = fileutil.ConflictPolicy(batchOnConflict)
+	return cfg, op, nil
+}
+
+// batchErrorHandler 根据命令行参数生成错误处理器（所有错误类型使用相同的重试参数）
+func batchErrorHandler() (*fileutil.ErrorHandler, error) {
+	if batchMaxRetries < 0 {
+		return nil, fmt.Errorf("最大重试次数不能为负数")
+	}
+	policy := fileutil.RetryPolicy{
+		MaxAttempts:  batchMaxRetries + 1,
+		InitialDelay: batchRetryInterval,
+		Multiplier:   batchRetryFactor,
+		MaxDelay:     batchRetryMaxDelay,
+		Jitter:       batchRetryJitter,
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("重试参数无效: %w", err)
+	}
+	if batchRetryBudget < 0 || batchRetryWait < 0 {
+		return nil, fmt.Errorf("重试预算不能为负数")
+	}
+
+	handler := fileutil.NewErrorHandler()
+	for _, et := range fileutil.ErrorTypes() {
+		handler.SetRetryPolicy(et, policy)
+	}
+	handler.SetRetryBudget(fileutil.RetryBudget{MaxRetries: batchRetryBudget, MaxWait: batchRetryWait})
+
+	breaker := fileutil.BreakerConfig{Threshold: batchTripThreshold, Window: batchTripWindow}
+	if err := breaker.Validate(); err != nil {
+		return nil, err
+	}
+	onTrip, err := batchTripFunc()
+	if err != nil {
+		return nil, err
+	}
+	handler.SetBreaker(breaker)
+	handler.SetTripFunc(onTrip)
+	return handler, nil
+}
+
+// tripPrompt --on-trip的交互询问方式
+const tripPrompt = "prompt"
+
+// batchTripFunc 根据--on-trip生成熔断处理函数：交互询问，或自动作出指定的选择
+// 标准输入不是终端时无法询问，按--on-trip-fallback的选择处理并在启动时提示
+func batchTripFunc() (fileutil.TripFunc, error) {
+	fallback, err := fileutil.ParseTripDecision(batchTripFallback)
+	if err != nil {
+		return nil, fmt.Errorf("--on-trip-fallback: %w", err)
+	}
+	if batchOnTrip == tripPrompt {
+		if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+			return promptTrip, nil
+		}
+		fmt.Fprintf(os.Stderr, "标准输入不是终端，熔断时无法询问，将自动选择: %s（可用 --on-trip-fallback 修改）\n", fallback.Label())
+		return autoTrip(fallback), nil
+	}
+	decision, err := fileutil.ParseTripDecision(batchOnTrip)
+	if err != nil {
+		return nil, err
+	}
+	return autoTrip(decision), nil
+}
+
+// autoTrip 熔断时输出原因并自动作出选择
+func autoTrip(decision fileutil.TripDecision) fileutil.TripFunc {
+	return func(_ context.Context, trip fileutil.Trip) fileutil.TripDecision {
+		fmt.Fprintf(os.Stderr, "\n⚠️ 熔断: %s\n自动选择: %s\n", trip, decision.Label())
+		return decision
+	}
+}
+
+// stdinAnswers 交互询问时从标准输入读到的回答，由readAnswers在首次询问时启动的唯一goroutine逐行写入，
+// 读取结束（EOF或出错）后关闭，此时收到的回答为空行
+var (
+	stdinAnswers = make(chan string)
+	stdinOnce    sync.Once
+)
+
+// readAnswers 逐行读取标准输入，直到读取结束
+func readAnswers() {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			stdinAnswers <- strings.ToLower(strings.TrimSpace(line))
+		}
+		if err != nil {
+			close(stdinAnswers)
+			return
+		}
+	}
+}
+
+// promptTrip 熔断时暂停所有Worker，在终端询问继续、跳过此类错误还是终止
+func promptTrip(ctx context.Context, trip fileutil.Trip) fileutil.TripDecision {
+	fmt.Fprintf(os.Stderr, "\n⚠️ 熔断: %s\n所有Worker已暂停。\n", trip)
+	stdinOnce.Do(func() { go readAnswers() })
+	for {
+		fmt.Fprint(os.Stderr, "继续(r) / 跳过此类错误(s) / 终止(a)? ")
+		select {
+		case answer := <-stdinAnswers:
+			switch answer {
+			case "r", "resume":
+				return fileutil.TripResume
+			case "s", "skip":
+				return fileutil.TripSkip
+			case "a", "abort", "":
+				return fileutil.TripAbort
+			}
+		case <-ctx.Done():
+			return fileutil.TripAbort
+		}
+	}
+}
+
+// batchFilter 根据筛选配置文件和命令行参数生成扫描筛选条件，未设置任何条件时返回nil
+func batchFilter() (*fileutil.Filter, error) {
+	filter := &fileutil.Filter{}
+	if batchFilterFile != "" {
+		loaded, err := fileutil.LoadFilter(batchFilterFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取筛选配置失败: %w", err)
+		}
+		filter = loaded
+	}
+	filter.Include = append(filter.Include, batchInclude...)
+	filter.Exclude = append(filter.Exclude, batchExclude...)
+
+	var err error
+	if batchMinSize != "" {
+		if filter.MinSize, err = fileutil.ParseSize(batchMinSize); err != nil {
+			return nil, err
+		}
+	}
+	if batchMaxSize != "" {
+		if filter.MaxSize, err = fileutil.ParseSize(batchMaxSize); err != nil {
+			return nil, err
+		}
+	}
+	now := time.Now()
+	if batchAfter != "" {
+		if filter.ModifiedAfter, err = fileutil.ParseTimeBound(batchAfter, now); err != nil {
+			return nil, err
+		}
+	}
+	if batchBefore != "" {
+		if filter.ModifiedBefore, err = fileutil.ParseTimeBound(batchBefore, now); err != nil {
+			return nil, err
+		}
+	}
+	filter.SkipHidden = filter.SkipHidden || batchSkipHidden
+	if batchIgnoreFile != "" {
+		filter.IgnoreFile = batchIgnoreFile
+	}
+
+	if batchSaveFilter != "" {
+		if err := filter.Save(batchSaveFilter); err != nil {
+			return nil, fmt.Errorf("保存筛选配置失败: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "筛选配置已保存: %s（使用 --filter-file %s 加载）\n", batchSaveFilter, batchSaveFilter)
+	}
+	if filter.IsZero() {
+		return nil, nil
+	}
+	return filter, nil
+}
+
+// runBatchPreview 预览重命名规则作用于前N个文件的结果
+func runBatchPreview() error {
+	if batchPlan != "" {
+		return fmt.Errorf("--preview 不能与 --plan 同时使用")
+	}
+	cfg, op, err := batchConfig()
+	if err != nil {
+		return err
+	}
+	if !op.Info().Renames {
+		return fmt.Errorf("%s模式不会重命名文件，无需预览", cfg.Mode)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	previews, err := fileutil.PreviewRename(ctx, cfg, batchPreview)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "原文件\t新名称")
+	for _, p := range previews {
+		if errors.Is(p.Err, fileutil.ErrNoMatch) {
+			fmt.Fprintf(w, "%s\t（不匹配，跳过）\n", p.Old)
+		} else if p.Err != nil {
+			fmt.Fprintf(w, "%s\t错误: %v\n", p.Old, p.Err)
+		} else {
+			fmt.Fprintf(w, "%s\t%s\n", p.Old, p.New)
+		}
+	}
+	return w.Flush()
+}
+
+// runBatchPlan 生成执行计划并输出（或保存），不修改任何文件
+func runBatchPlan() error {
+	if batchPlan != "" {
+		return fmt.Errorf("--dry-run/--save-plan 不能与 --plan 同时使用")
+	}
+	cfg, _, err := batchConfig()
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	plan, err := fileutil.BuildPlan(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	if batchSavePlan != "" {
+		if err := plan.Save(batchSavePlan); err != nil {
+			return fmt.Errorf("保存执行计划失败: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "执行计划已保存: %s（使用 --plan %s 执行）\n", batchSavePlan, batchSavePlan)
+	}
+
+	switch batchPlanFormat {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(plan)
+	case "table":
+		printPlanTable(plan)
+		return nil
+	}
+	return fmt.Errorf("不支持的计划输出格式: %s（可选：table/json）", batchPlanFormat)
+}
+
+// printPlanTable 以表格形式输出执行计划
+func printPlanTable(plan *fileutil.Plan) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "源文件\t目标\t大小\t冲突")
+	for _, item := range plan.Items {
+		target := item.Target
+		if target == "" {
+			target = "-"
+		}
+		var conflicts []string
+		for _, c := range item.Conflicts {
+			conflicts = append(conflicts, c.Label())
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", item.Source, target, fileutil.FormatBytes(item.Size), strings.Join(conflicts, ","))
+	}
+	w.Flush()
+
+	fmt.Printf("\n执行计划（%s）: 文件 %d 个, 总大小 %s, 有冲突 %d 个\n",
+		plan.Mode, len(plan.Items), fileutil.FormatBytes(plan.TotalBytes), plan.Conflicts)
+	if plan.Unmatched > 0 {
+		fmt.Printf("不匹配重命名规则、将被跳过的文件 %d 个\n", plan.Unmatched)
+	}
+	if len(plan.Filtered) > 0 {
+		fmt.Printf("扫描筛选排除: %s\n", fileutil.FormatFiltered(plan.Filtered))
+	}
+}
+
+// runBatch 核心批处理逻辑
+func runBatch() (fileutil.Summary, error) {
+	cfg, op, err := batchConfig()
+	if err != nil {
+		return fileutil.Summary{}, err
+	}
+	cfg.JournalPath = batchJournal
+	if cfg.JournalPath == "" {
+		path, err := fileutil.DefaultJournalPath(cfg)
+		if err != nil {
+			return fileutil.Summary{}, err
+		}
+		cfg.JournalPath = path
+	}
+	if op.Info().Destructive {
+		cfg.UndoPath = batchUndoLog
+		if cfg.UndoPath == "" {
+			path, err := fileutil.DefaultUndoPath(cfg.Mode)
+			if err != nil {
+				return fileutil.Summary{}, err
+			}
+			cfg.UndoPath = path
+		}
+	}
+	scheduler := fileutil.NewScheduler(cfg)
+
+	// 收到SIGINT/SIGTERM时取消批处理，中断进行中的复制和哈希计算
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	results, err := scheduler.Start(ctx)
+	if err != nil {
+		return fileutil.Summary{}, err
+	}
+	for _, short := range scheduler.Snapshot().SpaceShort {
+		fmt.Fprintf(os.Stderr, "⚠️ 目标磁盘空间可能不足: %s\n", short)
+	}
+
+	current := 0
+	interrupted := ctx.Done()
+	var deadline <-chan time.Time
+	for {
+		select {
+		case res, ok := <-results:
+			if !ok {
+				summary := scheduler.Wait()
+				printBatchSummary(summary)
+				return summary, nil
+			}
+			current++
+			printBatchResult(current, progressTotal(scheduler), res)
+		case <-interrupted:
+			// 停止派发新任务，等待进行中的任务退出
+			fmt.Fprintln(os.Stderr, "\n收到退出信号，正在停止...")
+			interrupted = nil
+			deadline = time.After(shutdownTimeout)
+		case <-deadline:
+			fmt.Fprintf(os.Stderr, "等待任务退出超时（%v），强制退出\n", shutdownTimeout)
+			// 取走已完成但尚未输出的结果，使其计入断点文件，再写出断点文件
+			for drained := false; !drained; {
+				select {
+				case res, ok := <-results:
+					if !ok {
+						// 任务恰好在超时时结束，按正常结束处理
+						summary := scheduler.Wait()
+						printBatchSummary(summary)
+						return summary, nil
+					}
+					current++
+					printBatchResult(current, progressTotal(scheduler), res)
+				default:
+					drained = true
+				}
+			}
+			if err := scheduler.Flush(); err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+			}
+			summary := scheduler.Snapshot()
+			summary.Interrupted = true
+			summary.Canceled = summary.Total - summary.Success - summary.Skipped - summary.Failed
+			printBatchSummary(summary)
+			return summary, nil
+		}
+	}
+}
+
+// progressTotal 返回进度显示的总数，仍在扫描源目录时加上+表示总数还会增加
+func progressTotal(scheduler *fileutil.Scheduler) string {
+	if scheduler.Scanning() {
+		return fmt.Sprintf("%d+", scheduler.Total())
+	}
+	return strconv.Itoa(scheduler.Total())
+}
+
+// printBatchResult 输出单个文件的处理结果
+func printBatchResult(current int, total string, res fileutil.Result) {
+	status := "成功"
+	if res.Unchanged {
+		status = "未变化"
+	}
+	switch {
+	case res.Conflict == fileutil.ConflictSkipped:
+		status = "跳过（目标已存在）"
+	case res.Skipped && res.Err == nil:
+		status = "跳过（不匹配重命名规则）"
+	case res.Conflict != fileutil.ConflictNone:
+		status += "（" + res.Conflict.Label() + "）"
+	}
+	if res.Err != nil {
+		if res.Skipped {
+			status = fmt.Sprintf("跳过: %v", res.Err)
+		} else {
+			status = fmt.Sprintf("失败: %v", res.Err)
+		}
+	}
+
+	retryInfo := ""
+	if res.Retried > 0 {
+		retryInfo = fmt.Sprintf(" (重试%d次)", res.Retried)
+	}
+
+	line := fmt.Sprintf("[%d/%s] %s", current, total, res.OldName)
+	if res.NewName != "" && res.NewName != res.OldName {
+		line += " -> " + res.NewName
+	}
+	if len(res.SrcHashes) > 0 {
+		line += " | 源 " + fileutil.FormatHashes(res.SrcHashes)
+	}
+	if len(res.DstHashes) > 0 {
+		verifyStr := "一致"
+		if !res.Verified {
+			verifyStr = "不一致"
+		}
+		line += fmt.Sprintf(" | 目标 %s | 校验: %s", fileutil.FormatHashes(res.DstHashes), verifyStr)
+	} else if res.VerifySkipped {
+		line += " | 校验: 未要求"
+	}
+
+	if res.Err != nil && !res.Skipped {
+		fmt.Fprintf(os.Stderr, "%s%s | %s\n", line, retryInfo, status)
+		return
+	}
+	fmt.Printf("%s%s | %s\n", line, retryInfo, status)
+	if len(res.MetaErrs) > 0 {
+		fmt.Fprintf(os.Stderr, "  未能保留: %s\n", fileutil.FormatMetaFailures(res.MetaErrs))
+	}
+}
+
+// printBatchSummary 输出最终统计
+func printBatchSummary(summary fileutil.Summary) {
+	if summary.Aborted {
+		fmt.Println("\n⚠️ 检测到严重错误，任务已中止！")
+	} else if summary.Interrupted {
+		fmt.Println("\n⚠️ 任务已被中断！")
+	}
+	if summary.Manifest != "" {
+		fmt.Printf("\n校验清单已写入: %s\n", summary.Manifest)
+	} else if summary.ManifestErr != nil {
+		fmt.Fprintf(os.Stderr, "\n写出校验清单失败: %v\n", summary.ManifestErr)
+	}
+	if summary.Deleted > 0 {
+		fmt.Printf("\n已删除目标目录中的多余文件 %d 个\n", summary.Deleted)
+	}
+	if summary.DeleteErr != nil {
+		fmt.Fprintf(os.Stderr, "\n删除多余文件失败: %v\n", summary.DeleteErr)
+	}
+	if summary.TempCleaned > 0 {
+		fmt.Printf("\n已清理上次中断遗留的临时文件 %d 个\n", summary.TempCleaned)
+	}
+	if summary.UndoLog != "" {
+		fmt.Printf("\n撤销日志: %s（可使用 filetool undo 还原）\n", summary.UndoLog)
+	}
+	if summary.UndoErr != nil {
+		fmt.Fprintf(os.Stderr, "\n%v\n", summary.UndoErr)
+	}
+	if summary.Resumed > 0 {
+		fmt.Printf("\n断点续作：跳过上次已完成的文件 %d 个\n", summary.Resumed)
+	}
+	if summary.JournalErr != nil {
+		fmt.Fprintf(os.Stderr, "\n%v\n", summary.JournalErr)
+	} else if summary.Journal != "" && (summary.Failed > 0 || summary.Skipped > 0 || summary.Canceled > 0) {
+		fmt.Printf("\n断点文件: %s（使用相同参数加 --resume 继续）\n", summary.Journal)
+	}
+	fmt.Printf("\n任务结束！耗时: %v\n", summary.Duration)
+	fmt.Printf("最终统计: 成功 %d, 跳过 %d, 失败 %d, 未完成 %d / 总计 %d\n",
+		summary.Success, summary.Skipped, summary.Failed, summary.Canceled, summary.Total)
+	if summary.Unchanged > 0 {
+		fmt.Printf("其中未变化未复制 %d 个\n", summary.Unchanged)
+	}
+	if summary.Retries > 0 {
+		fmt.Printf("重试 %d 次，合计等待 %v\n", summary.Retries, summary.RetryWait.Round(time.Millisecond))
+	}
+	if summary.Trips > 0 {
+		fmt.Printf("熔断 %d 次\n", summary.Trips)
+	}
+	if summary.BudgetSpent {
+		fmt.Fprintf(os.Stderr, "重试预算已用尽，之后失败的文件未再重试\n")
+	}
+	if len(summary.Conflicts) > 0 {
+		fmt.Printf("目标已存在: %s\n", fileutil.FormatConflicts(summary.Conflicts))
+	}
+	if len(summary.MetaFailed) > 0 {
+		fmt.Fprintf(os.Stderr, "未能保留元数据的文件数: %s\n", fileutil.FormatMetaCounts(summary.MetaFailed))
+	}
+	if len(summary.Filtered) > 0 {
+		fmt.Printf("扫描筛选排除: %s\n", fileutil.FormatFiltered(summary.Filtered))
+	}
+	if len(summary.Stranded) > 0 {
+		fmt.Fprintf(os.Stderr, "\n以下文件未能移回原路径，仍在暂存路径中，请手动处理：\n")
+		for _, p := range summary.Stranded {
+			fmt.Fprintf(os.Stderr, "  %s\n", p)
+		}
+	}
+}
@@ -1,27 +1,27 @@
 package cmd
 
 import (
-	"crypto/md5"
-	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
-	"hash" // 新增：导入hash包（Hash类型属于这个包）
 	"io"
 	"os"
+	"strings"
+
+	"training-practice/internal/fileutil"
 
 	"github.com/spf13/cobra"
 )
 
 var (
-	algorithm string // 校验和算法（md5/sha256）
+	algorithm string // 校验和算法（md5/sha256等）
 	filePath  string // 目标文件路径
 )
 
 // checksumCmd 计算文件校验和
 var checksumCmd = &cobra.Command{
 	Use:   "checksum",
-	Short: "计算文件的校验和（MD5/SHA256）",
-	Long:  `指定文件路径和算法，计算并输出文件的MD5或SHA256校验和`,
+	Short: "计算文件的校验和（MD5/SHA256/BLAKE2b等）",
+	Long:  `指定文件路径和算法，计算并输出文件的校验和，支持与批处理相同的全部哈希算法`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := calculateChecksum(); err != nil {
 			fmt.Fprintf(os.Stderr, "计算校验和失败: %v\n", err)
@@ -35,7 +35,8 @@ func init() {
 	rootCmd.AddCommand(checksumCmd)
 
 	// 添加命令行参数
-	checksumCmd.Flags().StringVarP(&algorithm, "algorithm", "a", "md5", "校验和算法（可选：md5/sha256）")
+	checksumCmd.Flags().StringVarP(&algorithm, "algorithm", "a", "md5",
+		fmt.Sprintf("校验和算法（可选：%s）", strings.Join(fileutil.HashAlgorithms(), "/")))
 	checksumCmd.Flags().StringVarP(&filePath, "file", "f", "", "目标文件路径（必填）")
 	_ = checksumCmd.MarkFlagRequired("file") // 标记file为必填参数
 }
@@ -49,15 +50,10 @@ func calculateChecksum() error {
 	}
 	defer file.Close()
 
-	// 选择算法（修复：将io.Hash改为hash.Hash）
-	var hashFunc hash.Hash
-	switch algorithm {
-	case "md5":
-		hashFunc = md5.New()
-	case "sha256":
-		hashFunc = sha256.New()
-	default:
-		return fmt.Errorf("不支持的算法: %s（仅支持md5/sha256）", algorithm)
+	// 选择算法
+	hashFunc, err := fileutil.NewHash(algorithm)
+	if err != nil {
+		return err
 	}
 
 	// 读取文件并计算哈希
@@ -1,25 +1,29 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"training-practice/internal/fileutil"
 
 	"github.com/spf13/cobra"
 )
 
 var (
-	srcCopyPath string // 源文件路径
-	dstCopyPath string // 目标文件路径
-	overwrite   bool   // 是否覆盖已存在的目标文件
+	srcCopyPath   string   // 源文件路径
+	dstCopyPath   string   // 目标文件路径
+	overwrite     bool     // 是否覆盖已存在的目标文件
+	preserveAttrs []string // 复制时保留的元数据（copy和move共用）
 )
 
 // copyCmd 复制文件
 var copyCmd = &cobra.Command{
 	Use:   "copy",
 	Short: "复制文件到指定路径",
-	Long:  `将源文件复制到目标路径，支持覆盖已存在的文件（需显式指定--overwrite）`,
+	Long:  `将源文件复制到目标路径，支持覆盖已存在的文件（需显式指定--overwrite），可通过--preserve选择保留的时间、权限、属主、扩展属性和ACL`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := copyFile(); err != nil {
 			fmt.Fprintf(os.Stderr, "复制文件失败: %v\n", err)
@@ -36,12 +40,18 @@ func init() {
 	copyCmd.Flags().StringVarP(&srcCopyPath, "source", "s", "", "源文件路径（必填）")
 	copyCmd.Flags().StringVarP(&dstCopyPath, "dest", "d", "", "目标文件路径（必填）")
 	copyCmd.Flags().BoolVarP(&overwrite, "overwrite", "o", false, "覆盖已存在的目标文件")
+	addPreserveFlag(copyCmd)
 	_ = copyCmd.MarkFlagRequired("source")
 	_ = copyCmd.MarkFlagRequired("dest")
 }
 
 // copyFile 核心复制逻辑
 func copyFile() error {
+	preserve, err := fileutil.ParsePreserve(preserveAttrs)
+	if err != nil {
+		return err
+	}
+
 	// 检查源文件是否存在
 	srcStat, err := os.Stat(srcCopyPath)
 	if err != nil {
@@ -62,31 +72,28 @@ func copyFile() error {
 		return fmt.Errorf("创建目标目录失败: %w", err)
 	}
 
-	// 打开源文件
-	srcFile, err := os.Open(srcCopyPath)
+	// 原子复制文件内容，并保留指定的元数据
+	metaErrs, err := fileutil.CopyFile(context.Background(), srcCopyPath, dstCopyPath, preserve)
 	if err != nil {
-		return fmt.Errorf("打开源文件失败: %w", err)
+		return fmt.Errorf("复制文件内容失败: %w", err)
 	}
-	defer srcFile.Close()
-
-	// 创建目标文件
-	dstFile, err := os.Create(dstCopyPath)
-	if err != nil {
-		return fmt.Errorf("创建目标文件失败: %w", err)
+	if len(metaErrs) > 0 {
+		fmt.Fprintf(os.Stderr, "未能保留: %s\n", fileutil.FormatMetaFailures(metaErrs))
 	}
-	defer dstFile.Close()
+	return nil
+}
 
-	// 复制文件内容
-	_, err = io.Copy(dstFile, srcFile)
-	if err != nil {
-		return fmt.Errorf("复制文件内容失败: %w", err)
-	}
+// addPreserveFlag 为复制类命令添加--preserve参数
+func addPreserveFlag(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&preserveAttrs, "preserve", fileutil.DefaultPreserve.Names(),
+		fmt.Sprintf("复制时保留的元数据（可选：%s/all/none）", strings.Join(preserveChoices(), "/")))
+}
 
-	// 同步文件到磁盘
-	if err := dstFile.Sync(); err != nil {
-		return fmt.Errorf("同步文件失败: %w", err)
+// preserveChoices 返回--preserve参数的可选项说明
+func preserveChoices() []string {
+	var attrs []string
+	for _, a := range fileutil.MetaAttrs() {
+		attrs = append(attrs, fmt.Sprintf("%s(%s)", a, a.Label()))
 	}
-
-	// 保留源文件权限
-	return os.Chmod(dstCopyPath, srcStat.Mode())
+	return attrs
 }
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"training-practice/internal/fileutil"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffFormat    string // 输出格式
+	diffAlgorithm string // 哈希算法
+	diffWorkers   int    // 并发Worker数
+	diffQuick     bool   // 按大小+修改时间快速判断
+	diffAll       bool   // 文本输出时也列出相同的文件
+)
+
+// diffCmd 按内容比较两个目录
+var diffCmd = &cobra.Command{
+	Use:   "diff A B",
+	Short: "按内容比较两个目录",
+	Long: `比较目录A和目录B下的所有文件，将每个相对路径归类为
+identical（内容相同）、differs（内容不同）、only_a（仅在A中）、only_b（仅在B中），
+无法读取的文件归为 error。
+
+大小不同的文件直接判定为不同；使用 --quick 时大小和修改时间都相同的文件直接判定为相同；
+其余文件使用Worker Pool并行计算哈希后比较。
+两个目录内容完全一致时退出码为0，否则为1。`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		same, err := runDiff(args[0], args[1])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "比较失败: %v\n", err)
+			os.Exit(2)
+		}
+		if !same {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	// 添加参数
+	diffCmd.Flags().StringVarP(&diffFormat, "format", "f", "text", "输出格式：text/json/csv")
+	diffCmd.Flags().StringVarP(&diffAlgorithm, "algorithm", "a", fileutil.DefaultAlgorithm,
+		fmt.Sprintf("哈希算法（可选：%s）", strings.Join(fileutil.HashAlgorithms(), "/")))
+	diffCmd.Flags().IntVarP(&diffWorkers, "workers", "w", 4, "并发Worker数")
+	diffCmd.Flags().BoolVarP(&diffQuick, "quick", "q", false, "大小和修改时间都相同时视为相同，不计算哈希")
+	diffCmd.Flags().BoolVar(&diffAll, "all", false, "文本输出时也列出内容相同的文件")
+}
+
+// runDiff 核心比较逻辑，返回两个目录内容是否完全一致
+func runDiff(rootA, rootB string) (bool, error) {
+	switch diffFormat {
+	case "text", "json", "csv":
+	default:
+		return false, fmt.Errorf("不支持的输出格式: %s（可选：text/json/csv）", diffFormat)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	report, err := fileutil.DiffTrees(ctx, fileutil.DiffOptions{
+		RootA:     rootA,
+		RootB:     rootB,
+		Algorithm: diffAlgorithm,
+		Workers:   diffWorkers,
+		Quick:     diffQuick,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	switch diffFormat {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(report)
+	case "csv":
+		err = printDiffCSV(report)
+	default:
+		printDiffText(report)
+	}
+	if err != nil {
+		return false, err
+	}
+	return report.Counts[fileutil.DiffIdentical] == len(report.Entries), nil
+}
+
+// diffMarks 文本输出中各分类的标记
+var diffMarks = map[fileutil.DiffStatus]string{
+	fileutil.DiffIdentical: "=",
+	fileutil.DiffDiffers:   "M",
+	fileutil.DiffOnlyA:     "-",
+	fileutil.DiffOnlyB:     "+",
+	fileutil.DiffError:     "!",
+}
+
+// printDiffText 以 "标记 路径" 的形式逐行输出，最后输出统计
+func printDiffText(report *fileutil.DiffReport) {
+	for _, e := range report.Entries {
+		if e.Status == fileutil.DiffIdentical && !diffAll {
+			continue
+		}
+		if e.Err != "" {
+			fmt.Printf("%s %s (%s)\n", diffMarks[e.Status], e.Path, e.Err)
+			continue
+		}
+		fmt.Printf("%s %s\n", diffMarks[e.Status], e.Path)
+	}
+
+	counts := report.Counts
+	fmt.Printf("\n比较完成（%s）: 相同 %d, 不同 %d, 仅在A %d, 仅在B %d",
+		strings.ToUpper(report.Algorithm),
+		counts[fileutil.DiffIdentical], counts[fileutil.DiffDiffers],
+		counts[fileutil.DiffOnlyA], counts[fileutil.DiffOnlyB])
+	if counts[fileutil.DiffError] > 0 {
+		fmt.Printf(", 无法读取 %d", counts[fileutil.DiffError])
+	}
+	fmt.Println()
+}
+
+// printDiffCSV 输出CSV（首行为表头）
+func printDiffCSV(report *fileutil.DiffReport) error {
+	w := csv.NewWriter(os.Stdout)
+	w.Write([]string{"path", "status", "by", "size_a", "size_b", "hash_a", "hash_b", "error"})
+	for _, e := range report.Entries {
+		w.Write([]string{
+			e.Path, string(e.Status), e.By,
+			strconv.FormatInt(e.SizeA, 10), strconv.FormatInt(e.SizeB, 10),
+			e.HashA, e.HashB, e.Err,
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 
+	"training-practice/internal/fileutil"
+
 	"github.com/spf13/cobra"
 )
 
@@ -35,12 +37,18 @@ func init() {
 	moveCmd.Flags().StringVarP(&srcMovePath, "source", "s", "", "源文件路径（必填）")
 	moveCmd.Flags().StringVarP(&dstMovePath, "dest", "d", "", "目标文件路径（必填）")
 	moveCmd.Flags().BoolVarP(&forceMove, "force", "f", false, "强制覆盖已存在的目标文件")
+	addPreserveFlag(moveCmd)
 	_ = moveCmd.MarkFlagRequired("source")
 	_ = moveCmd.MarkFlagRequired("dest")
 }
 
 // moveFile 核心移动逻辑
 func moveFile() error {
+	// 跨文件系统时降级为复制，提前检查要保留的元数据
+	if _, err := fileutil.ParsePreserve(preserveAttrs); err != nil {
+		return err
+	}
+
 	// 检查源文件是否存在
 	srcStat, err := os.Stat(srcMovePath)
 	if err != nil {
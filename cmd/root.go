@@ -12,7 +12,7 @@ import (
 var rootCmd = &cobra.Command{
 	Use:   "filetool",
 	Short: "高并发文件处理工具",
-	Long:  `支持文件MD5计算、重命名、复制、复制+重命名的高并发处理工具，带可视化界面`,
+	Long:  `支持文件MD5计算、重命名、复制、复制+重命名的高并发处理工具，带可视化界面，也可通过batch子命令无界面运行`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// 启动UI界面
 		ui.Run()
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"training-practice/internal/fileutil"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	undoDryRun bool // 只检查不移动
+)
+
+// undoCmd 按撤销日志还原重命名/移动批处理
+var undoCmd = &cobra.Command{
+	Use:   "undo JOURNAL",
+	Short: "按撤销日志还原重命名/移动批处理",
+	Long: `重命名和移动批处理会把每个文件的原路径、新路径和哈希写入撤销日志
+（默认位于用户缓存目录的 filetool/undo 下，路径在批处理结束时输出）。
+
+undo 按与执行相反的顺序把文件移回原路径，每一步先校验新文件的哈希；
+新文件缺失、内容已改变或原路径已被占用时不做修改并报告，
+执行时被覆盖的文件无法恢复，也会在结束时报告。`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ok, err := runUndo(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "撤销失败: %v\n", err)
+			os.Exit(1)
+		}
+		if !ok {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(undoCmd)
+
+	// 添加参数
+	undoCmd.Flags().BoolVarP(&undoDryRun, "dry-run", "n", false, "只检查能否还原，不移动文件")
+}
+
+// runUndo 核心撤销逻辑，返回是否全部还原
+func runUndo(journal string) (bool, error) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	report, err := fileutil.Undo(ctx, journal, undoDryRun)
+	if report == nil {
+		return false, err
+	}
+
+	action := "已还原"
+	if undoDryRun {
+		action = "可还原"
+	}
+	for _, e := range report.Entries {
+		if e.Err != nil {
+			fmt.Fprintf(os.Stderr, "无法还原: %s -> %s | %v\n", e.New, e.Old, e.Err)
+			continue
+		}
+		fmt.Printf("%s: %s -> %s\n", action, e.New, e.Old)
+		if e.Replaced {
+			fmt.Fprintf(os.Stderr, "  注意: %s 上原有的文件在执行时已被覆盖，无法恢复\n", e.New)
+		}
+	}
+
+	title := "撤销完成"
+	if err != nil {
+		title = "撤销已中断"
+	}
+	fmt.Printf("\n%s（%s）: %s %d, 无法还原 %d",
+		title, report.Mode, action, report.Counts[fileutil.UndoRestored], report.Counts[fileutil.UndoFailed])
+	if report.Lost > 0 {
+		fmt.Printf(", 执行时被覆盖无法恢复 %d", report.Lost)
+	}
+	fmt.Println()
+	if err != nil {
+		// 被中断时已处理的部分照常输出，便于核对撤销日志与实际文件
+		return false, fmt.Errorf("已中断，还有%d条记录未处理: %w", report.Pending, err)
+	}
+	return report.Counts[fileutil.UndoFailed] == 0, nil
+}
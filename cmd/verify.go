@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"training-practice/internal/fileutil"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifyManifest  string // 校验清单路径
+	verifyRoot      string // 清单相对路径的根目录
+	verifyAlgorithm string // 哈希算法
+	verifyWorkers   int    // 并发Worker数
+	verifyQuiet     bool   // 只输出异常项
+	verifyStrict    bool   // 新增文件也视为校验失败
+)
+
+// verifyCmd 按校验清单并行校验目录
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "按md5sum/sha256sum格式的校验清单并行校验目录",
+	Long: `读取校验清单，使用Worker Pool并行重新计算目录下所有文件的哈希，
+逐个报告 OK（一致）、FAILED（不一致或无法读取）、MISSING（缺失）和 NEW（清单外的新文件）。
+存在FAILED或MISSING时以非零状态退出，可替代 md5sum -c。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ok, err := runVerify()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "校验失败: %v\n", err)
+			os.Exit(1)
+		}
+		if !ok {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+
+	// 添加参数
+	verifyCmd.Flags().StringVar(&verifyManifest, "manifest", "", "校验清单文件路径（必填）")
+	verifyCmd.Flags().StringVarP(&verifyRoot, "root", "r", "", "清单中相对路径的根目录（默认为清单所在目录）")
+	verifyCmd.Flags().StringVarP(&verifyAlgorithm, "algorithm", "a", "",
+		fmt.Sprintf("哈希算法（默认根据清单推断，可选：%s）", strings.Join(fileutil.HashAlgorithms(), "/")))
+	verifyCmd.Flags().IntVarP(&verifyWorkers, "workers", "w", 4, "并发Worker数")
+	verifyCmd.Flags().BoolVarP(&verifyQuiet, "quiet", "q", false, "不输出校验一致的文件")
+	verifyCmd.Flags().BoolVar(&verifyStrict, "strict", false, "存在清单外的新文件时也以非零状态退出")
+	_ = verifyCmd.MarkFlagRequired("manifest")
+}
+
+// runVerify 核心校验逻辑，返回是否全部通过
+func runVerify() (bool, error) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	report, err := fileutil.VerifyManifest(ctx, fileutil.VerifyOptions{
+		ManifestPath: verifyManifest,
+		Root:         verifyRoot,
+		Algorithm:    verifyAlgorithm,
+		Workers:      verifyWorkers,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	for _, e := range report.Entries {
+		switch e.Status {
+		case fileutil.VerifyOK:
+			if !verifyQuiet {
+				fmt.Printf("%s: %s\n", e.Path, e.Status)
+			}
+		case fileutil.VerifyFailed:
+			if e.Err != nil {
+				fmt.Printf("%s: %s (%v)\n", e.Path, e.Status, e.Err)
+			} else {
+				fmt.Printf("%s: %s\n", e.Path, e.Status)
+			}
+		default:
+			fmt.Printf("%s: %s\n", e.Path, e.Status)
+		}
+	}
+
+	counts := report.Counts
+	fmt.Printf("\n校验完成（%s）: OK %d, FAILED %d, MISSING %d, NEW %d\n",
+		strings.ToUpper(report.Algorithm),
+		counts[fileutil.VerifyOK], counts[fileutil.VerifyFailed],
+		counts[fileutil.VerifyMissing], counts[fileutil.VerifyNew])
+
+	ok := counts[fileutil.VerifyFailed] == 0 && counts[fileutil.VerifyMissing] == 0
+	if verifyStrict && counts[fileutil.VerifyNew] > 0 {
+		ok = false
+	}
+	return ok, nil
+}
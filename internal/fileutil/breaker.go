@@ -0,0 +1,242 @@
+package fileutil
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// BreakerConfig 定义熔断条件：时间窗口内同一类型的错误达到阈值时熔断；
+// 磁盘空间不足和只读文件系统属于系统性故障，出现一次即熔断；策略为跳过的错误不计入
+type BreakerConfig struct {
+	Threshold int           // 时间窗口内同类错误的数量阈值（0表示只在系统性故障时熔断）
+	Window    time.Duration // 统计错误数量的时间窗口
+}
+
+// DefaultBreaker 默认熔断条件：30秒内同类错误达到10次
+var DefaultBreaker = BreakerConfig{Threshold: 10, Window: 30 * time.Second}
+
+// Validate 检查熔断条件是否有效
+func (c BreakerConfig) Validate() error {
+	if c.Threshold < 0 {
+		return fmt.Errorf("熔断阈值不能为负数")
+	}
+	if c.Threshold > 0 && c.Window <= 0 {
+		return fmt.Errorf("熔断时间窗口必须大于0")
+	}
+	return nil
+}
+
+// TripDecision 熔断后前端的选择
+type TripDecision string
+
+const (
+	TripResume TripDecision = "resume" // 继续：清空错误计数，触发熔断的任务按重试策略重新执行，继续后再次熔断时按失败处理
+	TripSkip   TripDecision = "skip"   // 跳过：此类错误的文件此后直接跳过，不再重试也不再熔断
+	TripAbort  TripDecision = "abort"  // 终止整个批处理
+)
+
+// tripDecisionLabels 熔断选择的显示名称
+var tripDecisionLabels = map[TripDecision]string{
+	TripResume: "继续",
+	TripSkip:   "跳过此类错误",
+	TripAbort:  "终止",
+}
+
+// TripDecisions 返回所有熔断选择
+func TripDecisions() []TripDecision {
+	return []TripDecision{TripResume, TripSkip, TripAbort}
+}
+
+// Label 返回熔断选择的显示名称
+func (d TripDecision) Label() string {
+	if label, ok := tripDecisionLabels[d]; ok {
+		return label
+	}
+	return string(d)
+}
+
+// ParseTripDecision 解析熔断选择
+func ParseTripDecision(s string) (TripDecision, error) {
+	d := TripDecision(s)
+	if _, ok := tripDecisionLabels[d]; !ok {
+		return "", fmt.Errorf("不支持的熔断选择: %s", s)
+	}
+	return d, nil
+}
+
+// Trip 描述一次熔断
+type Trip struct {
+	Type   ErrorType     // 触发熔断的错误类型
+	Count  int           // 时间窗口内该类错误的数量
+	Window time.Duration // 时间窗口
+	Fatal  bool          // 是否因系统性故障（磁盘空间不足、只读文件系统）熔断
+	Err    error         // 触发熔断的错误
+	Path   string        // 触发熔断的文件
+}
+
+// String 返回熔断原因的说明
+func (t Trip) String() string {
+	if t.Fatal {
+		return fmt.Sprintf("%s: %v", t.Type.Label(), t.Err)
+	}
+	return fmt.Sprintf("%v内出现%d次%s，最近一次: %v", t.Window, t.Count, t.Type.Label(), t.Err)
+}
+
+// TripFunc 熔断时由前端决定如何处理；调用期间所有Worker暂停，ctx取消时应尽快返回
+type TripFunc func(ctx context.Context, trip Trip) TripDecision
+
+// pause 一次熔断暂停：前端作出选择后关闭done
+type pause struct {
+	trip Trip
+	done chan struct{}
+}
+
+// tripRecord 某类错误最近一次熔断时前端的选择
+type tripRecord struct {
+	decision TripDecision
+	at       time.Time
+}
+
+// fatalError 判断错误类型是否属于出现一次即熔断的系统性故障
+func fatalError(t ErrorType) bool {
+	return t == ErrorDiskSpaceFull || t == ErrorReadOnlyFS
+}
+
+// SetBreaker 设置熔断条件
+func (h *ErrorHandler) SetBreaker(cfg BreakerConfig) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.breaker = cfg
+}
+
+// SetTripFunc 设置熔断时的处理函数（为空时不熔断，磁盘空间不足直接终止批处理）
+func (h *ErrorHandler) SetTripFunc(fn TripFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.onTrip = fn
+}
+
+// Trips 返回已发生的熔断次数
+func (h *ErrorHandler) Trips() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.trips
+}
+
+// waitResume 熔断暂停期间阻塞，直到前端作出选择或ctx取消
+func (h *ErrorHandler) waitResume(ctx context.Context) error {
+	h.mu.Lock()
+	p := h.paused
+	h.mu.Unlock()
+	if p == nil {
+		return nil
+	}
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// decide 返回失败任务的处理策略：先检查是否熔断，未熔断时按错误类型的策略处理；
+// started为本次执行开始的时间，熔断后选择继续时resumed为true（此时策略为PolicyRetry）
+func (h *ErrorHandler) decide(ctx context.Context, errorInfo ErrorInfo, started time.Time) (policy ErrorPolicy, resumed bool) {
+	decision, tripped := h.observe(ctx, errorInfo, started)
+	if !tripped {
+		return h.HandleError(errorInfo), false
+	}
+	switch decision {
+	case TripResume:
+		return PolicyRetry, true
+	case TripSkip:
+		return PolicySkip, false
+	}
+	return PolicyAbort, false
+}
+
+// observe 记录一次错误并判断是否熔断；熔断时暂停所有Worker并等待前端的选择。
+// 在前端作出选择之前就已开始的执行（熔断时正在进行的任务）出现同类错误时沿用这次的选择，不会再次询问
+func (h *ErrorHandler) observe(ctx context.Context, errorInfo ErrorInfo, started time.Time) (TripDecision, bool) {
+	h.mu.Lock()
+	for h.paused != nil {
+		p := h.paused
+		h.mu.Unlock()
+		select {
+		case <-p.done:
+		case <-ctx.Done():
+			return TripAbort, true
+		}
+		h.mu.Lock()
+	}
+	if h.onTrip == nil || h.abortFlag || h.muted[errorInfo.Type] || h.policy(errorInfo.Type) == PolicySkip {
+		h.mu.Unlock()
+		return "", false
+	}
+	if last, ok := h.decided[errorInfo.Type]; ok && started.Before(last.at) {
+		h.mu.Unlock()
+		return last.decision, true
+	}
+
+	trip, ok := h.count(errorInfo)
+	if !ok {
+		h.mu.Unlock()
+		return "", false
+	}
+	p := &pause{trip: trip, done: make(chan struct{})}
+	h.paused = p
+	h.trips++
+	onTrip := h.onTrip
+	h.mu.Unlock()
+
+	decision := onTrip(ctx, trip)
+	if ctx.Err() != nil {
+		decision = TripAbort
+	}
+
+	h.mu.Lock()
+	switch decision {
+	case TripResume:
+		delete(h.bursts, trip.Type)
+	case TripSkip:
+		h.muted[trip.Type] = true
+		h.policies[trip.Type] = PolicySkip
+	default:
+		decision = TripAbort
+		h.abortFlag = true
+	}
+	h.decided[trip.Type] = tripRecord{decision: decision, at: time.Now()}
+	h.paused = nil
+	close(p.done)
+	h.mu.Unlock()
+	return decision, true
+}
+
+// count 把错误计入时间窗口，达到熔断条件时返回熔断信息（调用方持有h.mu）
+func (h *ErrorHandler) count(errorInfo ErrorInfo) (Trip, bool) {
+	trip := Trip{Type: errorInfo.Type, Count: 1, Fatal: true, Err: errorInfo.Err, Path: errorInfo.Path}
+	if fatalError(errorInfo.Type) {
+		return trip, true
+	}
+	if h.breaker.Threshold <= 0 {
+		return trip, false
+	}
+
+	now := time.Now()
+	times := h.bursts[errorInfo.Type]
+	start := 0
+	for start < len(times) && now.Sub(times[start]) > h.breaker.Window {
+		start++
+	}
+	times = append(times[start:], now)
+	h.bursts[errorInfo.Type] = times
+	if len(times) < h.breaker.Threshold {
+		return trip, false
+	}
+
+	trip.Count = len(times)
+	trip.Window = h.breaker.Window
+	trip.Fatal = false
+	return trip, true
+}
@@ -0,0 +1,340 @@
+package fileutil
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestBreakerCount 只统计时间窗口内的同类错误，系统性故障出现一次即熔断
+func TestBreakerCount(t *testing.T) {
+	tests := []struct {
+		name      string
+		breaker   BreakerConfig
+		errType   ErrorType
+		earlier   []time.Duration // 之前出现同类错误距今的时间
+		tripped   bool
+		count     int // 熔断时窗口内的错误数
+		remaining int // 统计后窗口内记录的错误数
+	}{
+		{
+			name:      "达到阈值",
+			breaker:   BreakerConfig{Threshold: 3, Window: 30 * time.Second},
+			errType:   ErrorIORead,
+			earlier:   []time.Duration{10 * time.Second, 5 * time.Second},
+			tripped:   true,
+			count:     3,
+			remaining: 3,
+		},
+		{
+			name:      "窗口外的错误不计入",
+			breaker:   BreakerConfig{Threshold: 3, Window: 30 * time.Second},
+			errType:   ErrorIORead,
+			earlier:   []time.Duration{40 * time.Second, 5 * time.Second},
+			tripped:   false,
+			remaining: 2,
+		},
+		{
+			name:      "未达到阈值",
+			breaker:   BreakerConfig{Threshold: 3, Window: 30 * time.Second},
+			errType:   ErrorIOWrite,
+			tripped:   false,
+			remaining: 1,
+		},
+		{
+			name:    "阈值为0时不按数量熔断",
+			breaker: BreakerConfig{},
+			errType: ErrorIORead,
+			earlier: []time.Duration{time.Second, time.Second},
+			tripped: false,
+		},
+		{
+			name:    "磁盘空间不足立即熔断",
+			breaker: BreakerConfig{},
+			errType: ErrorDiskSpaceFull,
+			tripped: true,
+			count:   1,
+		},
+		{
+			name:    "只读文件系统立即熔断",
+			breaker: DefaultBreaker,
+			errType: ErrorReadOnlyFS,
+			tripped: true,
+			count:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewErrorHandler()
+			h.SetBreaker(tt.breaker)
+			now := time.Now()
+			for _, d := range tt.earlier {
+				h.bursts[tt.errType] = append(h.bursts[tt.errType], now.Add(-d))
+			}
+			trip, tripped := h.count(ErrorInfo{Type: tt.errType, Err: errors.New("x"), Path: "a"})
+			if tripped != tt.tripped {
+				t.Fatalf("count() 熔断 = %v, 期望 %v", tripped, tt.tripped)
+			}
+			if tripped && (trip.Count != tt.count || trip.Fatal != fatalError(tt.errType) || trip.Path != "a") {
+				t.Errorf("Trip = %+v, 期望 Count %d", trip, tt.count)
+			}
+			if tt.breaker.Threshold > 0 && len(h.bursts[tt.errType]) != tt.remaining {
+				t.Errorf("窗口内记录的错误数 = %d, 期望 %d", len(h.bursts[tt.errType]), tt.remaining)
+			}
+		})
+	}
+}
+
+// tripRecorder 记录熔断处理函数被调用的次数，并作出指定的选择
+type tripRecorder struct {
+	decision TripDecision
+	trips    []Trip
+}
+
+func (r *tripRecorder) onTrip(_ context.Context, trip Trip) TripDecision {
+	r.trips = append(r.trips, trip)
+	return r.decision
+}
+
+// TestBreakerDecide 熔断后按前端的选择处理；策略为跳过的错误不计入，跳过此类错误后不再熔断
+func TestBreakerDecide(t *testing.T) {
+	type step struct {
+		errType ErrorType
+		policy  ErrorPolicy
+		resumed bool
+	}
+	tests := []struct {
+		name     string
+		decision TripDecision
+		steps    []step
+		trips    int
+		after    ErrorPolicy // 之后该类错误的策略
+		aborting bool
+	}{
+		{
+			name:     "跳过策略的错误不计入",
+			decision: TripAbort,
+			steps: []step{
+				{ErrorFileNotFound, PolicySkip, false},
+				{ErrorFileNotFound, PolicySkip, false},
+				{ErrorFileNotFound, PolicySkip, false},
+			},
+			trips: 0,
+			after: PolicySkip,
+		},
+		{
+			name:     "继续后重新计数",
+			decision: TripResume,
+			steps: []step{
+				{ErrorIORead, PolicyRetry, false},
+				{ErrorIORead, PolicyRetry, true},
+				{ErrorIORead, PolicyRetry, false},
+				{ErrorIORead, PolicyRetry, true},
+			},
+			trips: 2,
+			after: PolicyRetry,
+		},
+		{
+			name:     "跳过此类错误",
+			decision: TripSkip,
+			steps: []step{
+				{ErrorIORead, PolicyRetry, false},
+				{ErrorIORead, PolicySkip, false},
+				{ErrorIORead, PolicySkip, false},
+				{ErrorIORead, PolicySkip, false},
+			},
+			trips: 1,
+			after: PolicySkip,
+		},
+		{
+			name:     "终止",
+			decision: TripAbort,
+			steps: []step{
+				{ErrorIOWrite, PolicyRetry, false},
+				{ErrorIOWrite, PolicyAbort, false},
+				{ErrorIORead, PolicyAbort, false},
+			},
+			trips:    1,
+			after:    PolicyRetry,
+			aborting: true,
+		},
+		{
+			name:     "不同类型分别计数",
+			decision: TripAbort,
+			steps: []step{
+				{ErrorIORead, PolicyRetry, false},
+				{ErrorIOWrite, PolicyRetry, false},
+				{ErrorUnknown, PolicyRetry, false},
+			},
+			trips: 0,
+			after: PolicyRetry,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewErrorHandler()
+			h.SetBreaker(BreakerConfig{Threshold: 2, Window: time.Minute})
+			rec := &tripRecorder{decision: tt.decision}
+			h.SetTripFunc(rec.onTrip)
+
+			for i, s := range tt.steps {
+				policy, resumed := h.decide(context.Background(), ErrorInfo{Type: s.errType, Err: errors.New("x")}, time.Now())
+				if policy != s.policy || resumed != s.resumed {
+					t.Fatalf("第%d次 decide(%s) = (%v, %v), 期望 (%v, %v)", i+1, s.errType.Label(), policy, resumed, s.policy, s.resumed)
+				}
+			}
+			if len(rec.trips) != tt.trips || h.Trips() != tt.trips {
+				t.Errorf("熔断次数 = %d（处理函数调用%d次）, 期望 %d", h.Trips(), len(rec.trips), tt.trips)
+			}
+			if got := h.Policy(tt.steps[0].errType); got != tt.after {
+				t.Errorf("Policy(%s) = %v, 期望 %v", tt.steps[0].errType.Label(), got, tt.after)
+			}
+			if h.aborting() != tt.aborting {
+				t.Errorf("aborting() = %v, 期望 %v", h.aborting(), tt.aborting)
+			}
+		})
+	}
+}
+
+// TestBreakerReplay 熔断期间其他Worker暂停；熔断前已开始的执行出现同类错误时沿用这次的选择，之后开始的执行重新计数
+func TestBreakerReplay(t *testing.T) {
+	policies := map[TripDecision]ErrorPolicy{TripResume: PolicyRetry, TripSkip: PolicySkip, TripAbort: PolicyAbort}
+	for _, decision := range TripDecisions() {
+		t.Run(decision.Label(), func(t *testing.T) {
+			h := NewErrorHandler()
+			h.SetBreaker(BreakerConfig{Threshold: 1, Window: time.Minute})
+			entered := make(chan struct{})
+			release := make(chan struct{})
+			calls := 0
+			h.SetTripFunc(func(ctx context.Context, trip Trip) TripDecision {
+				calls++
+				close(entered)
+				<-release
+				return decision
+			})
+
+			info := ErrorInfo{Type: ErrorIORead, Err: errors.New("x")}
+			inFlight := time.Now()
+			first := make(chan ErrorPolicy)
+			go func() {
+				policy, _ := h.decide(context.Background(), info, time.Now())
+				first <- policy
+			}()
+			<-entered
+
+			// 暂停期间不开始新的执行，正在进行的任务失败后等待前端的选择
+			resumed := make(chan error)
+			go func() { resumed <- h.waitResume(context.Background()) }()
+			replayed := make(chan ErrorPolicy)
+			go func() {
+				policy, _ := h.decide(context.Background(), info, inFlight)
+				replayed <- policy
+			}()
+			select {
+			case <-resumed:
+				t.Fatal("熔断暂停期间 waitResume 提前返回")
+			case <-replayed:
+				t.Fatal("熔断暂停期间 decide 提前返回")
+			case <-time.After(20 * time.Millisecond):
+			}
+			close(release)
+
+			want := policies[decision]
+			if got := <-first; got != want {
+				t.Errorf("触发熔断的任务 = %v, 期望 %v", got, want)
+			}
+			if err := <-resumed; err != nil {
+				t.Errorf("waitResume() = %v", err)
+			}
+			if got := <-replayed; got != want {
+				t.Errorf("熔断前已开始的任务 = %v, 期望沿用 %v", got, want)
+			}
+			if calls != 1 {
+				t.Errorf("熔断处理函数调用 %d 次, 期望 1 次", calls)
+			}
+
+			// 选择继续后开始的执行重新计数，再次达到阈值时再次熔断
+			if decision == TripResume {
+				rec := &tripRecorder{decision: TripResume}
+				h.SetTripFunc(rec.onTrip)
+				if _, resumed := h.decide(context.Background(), info, time.Now()); !resumed || len(rec.trips) != 1 {
+					t.Errorf("继续后开始的执行未重新计数: 熔断 %d 次", len(rec.trips))
+				}
+			}
+		})
+	}
+}
+
+// flakyOperator 前flakyFailures次执行失败的测试操作
+type flakyOperator struct{}
+
+// flakyFailures flakyOperator剩余的失败次数，flakyRuns为已执行的次数
+var flakyFailures, flakyRuns int
+
+func (flakyOperator) Info() OperatorInfo    { return OperatorInfo{Name: "test_flaky", ReadOnly: true} }
+func (flakyOperator) Validate(t Task) error { return nil }
+func (flakyOperator) Apply(ctx context.Context, t Task) Result {
+	flakyRuns++
+	if flakyFailures > 0 {
+		flakyFailures--
+		return Result{OldName: t.Path, Err: errors.New("flaky")}
+	}
+	return Result{OldName: t.Path, NewName: t.Path}
+}
+
+// TestResumeRetries 熔断后选择继续时触发熔断的任务按重试策略重新执行，计入重试次数和重试预算；
+// 继续后再次熔断时该任务按失败处理，持续失败的任务执行次数有限
+func TestResumeRetries(t *testing.T) {
+	if _, ok := LookupOperator("test_flaky"); !ok {
+		if err := RegisterOperator(flakyOperator{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name       string
+		decision   TripDecision
+		attempts   int // 最多执行次数
+		failures   int
+		wantErr    bool
+		retried    int
+		skipped    bool
+		executions int
+		trips      int
+	}{
+		{"继续后成功", TripResume, 3, 1, false, 1, false, 2, 1},
+		{"继续后再次熔断", TripResume, 3, 2, true, 1, false, 2, 2},
+		{"持续失败", TripResume, 3, 1000, true, 1, false, 2, 2},
+		{"继续但不重试", TripResume, 1, 1, true, 0, false, 1, 1},
+		{"跳过", TripSkip, 3, 1, true, 0, true, 1, 1},
+		{"终止", TripAbort, 3, 1, true, 0, false, 1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewErrorHandler()
+			h.SetRetryPolicy(ErrorUnknown, RetryPolicy{MaxAttempts: tt.attempts})
+			h.SetBreaker(BreakerConfig{Threshold: 1, Window: time.Minute})
+			rec := &tripRecorder{decision: tt.decision}
+			h.SetTripFunc(rec.onTrip)
+			flakyFailures, flakyRuns = tt.failures, 0
+
+			res := ProcessFileWithRetry(Task{Path: "a.txt", Mode: "test_flaky"}, h)
+			if (res.Err != nil) != tt.wantErr || res.Retried != tt.retried || res.Skipped != tt.skipped {
+				t.Errorf("Err = %v, Retried = %d, Skipped = %v, 期望出错 %v, Retried %d, Skipped %v",
+					res.Err, res.Retried, res.Skipped, tt.wantErr, tt.retried, tt.skipped)
+			}
+			if flakyRuns != tt.executions {
+				t.Errorf("执行 %d 次, 期望 %d 次", flakyRuns, tt.executions)
+			}
+			if len(rec.trips) != tt.trips {
+				t.Errorf("熔断 %d 次, 期望 %d 次", len(rec.trips), tt.trips)
+			}
+			if retries, _, _ := h.RetryStats(); retries != tt.retried {
+				t.Errorf("RetryStats() 重试 %d 次, 期望 %d 次（计入重试预算）", retries, tt.retried)
+			}
+		})
+	}
+}
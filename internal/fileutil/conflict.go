@@ -0,0 +1,211 @@
+package fileutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ConflictPolicy 目标路径已存在文件时的处理策略
+type ConflictPolicy string
+
+const (
+	OnConflictOverwrite ConflictPolicy = "overwrite" // 覆盖
+	OnConflictSkip      ConflictPolicy = "skip"      // 跳过，保留已有文件
+	OnConflictKeepBoth  ConflictPolicy = "keep_both" // 保留两者，新文件自动编号为 "name (1).ext"
+	OnConflictNewer     ConflictPolicy = "newer"     // 源文件比已有文件新时覆盖，否则跳过
+	OnConflictDifferent ConflictPolicy = "different" // 内容（哈希）不同时覆盖，相同时跳过
+	OnConflictFail      ConflictPolicy = "fail"      // 报错，交给错误处理策略
+)
+
+// DefaultConflictPolicy 未指定时的冲突策略（不会破坏已有文件）
+const DefaultConflictPolicy = OnConflictSkip
+
+// conflictPolicies 按显示顺序排列的冲突策略及显示名称
+var conflictPolicies = []struct {
+	policy ConflictPolicy
+	label  string
+}{
+	{OnConflictSkip, "跳过"},
+	{OnConflictOverwrite, "覆盖"},
+	{OnConflictKeepBoth, "保留两者（自动编号）"},
+	{OnConflictNewer, "源文件较新时覆盖"},
+	{OnConflictDifferent, "内容不同时覆盖"},
+	{OnConflictFail, "报错"},
+}
+
+// ConflictPolicies 按显示顺序返回所有冲突策略
+func ConflictPolicies() []ConflictPolicy {
+	policies := make([]ConflictPolicy, 0, len(conflictPolicies))
+	for _, p := range conflictPolicies {
+		policies = append(policies, p.policy)
+	}
+	return policies
+}
+
+// Label 返回冲突策略的显示名称
+func (p ConflictPolicy) Label() string {
+	for _, c := range conflictPolicies {
+		if c.policy == p {
+			return c.label
+		}
+	}
+	return string(p)
+}
+
+// ValidateConflictPolicy 校验冲突策略（为空表示使用默认策略）
+func ValidateConflictPolicy(p ConflictPolicy) error {
+	if p == "" {
+		return nil
+	}
+	for _, c := range conflictPolicies {
+		if c.policy == p {
+			return nil
+		}
+	}
+	names := make([]string, 0, len(conflictPolicies))
+	for _, c := range conflictPolicies {
+		names = append(names, string(c.policy))
+	}
+	return fmt.Errorf("不支持的冲突策略: %s（可选：%s）", p, strings.Join(names, "/"))
+}
+
+// ConflictOutcome 单个文件遇到冲突时的实际处理结果
+type ConflictOutcome string
+
+const (
+	ConflictNone        ConflictOutcome = ""            // 目标不存在，没有冲突
+	ConflictOverwritten ConflictOutcome = "overwritten" // 已覆盖已有文件
+	ConflictSkipped     ConflictOutcome = "skipped"     // 保留已有文件，未处理
+	ConflictRenamed     ConflictOutcome = "renamed"     // 保留两者，输出到自动编号的新路径
+	ConflictFailed      ConflictOutcome = "failed"      // 按策略报错
+)
+
+// conflictOutcomeLabels 冲突处理结果的显示名称
+var conflictOutcomeLabels = map[ConflictOutcome]string{
+	ConflictOverwritten: "已覆盖目标",
+	ConflictSkipped:     "目标已存在，已跳过",
+	ConflictRenamed:     "目标已存在，已另存",
+	ConflictFailed:      "目标已存在",
+}
+
+// Label 返回冲突处理结果的显示名称
+func (o ConflictOutcome) Label() string {
+	return conflictOutcomeLabels[o]
+}
+
+// ConflictOutcomes 按显示顺序返回所有冲突处理结果（不含ConflictNone）
+func ConflictOutcomes() []ConflictOutcome {
+	return []ConflictOutcome{ConflictOverwritten, ConflictSkipped, ConflictRenamed, ConflictFailed}
+}
+
+// FormatConflicts 格式化各冲突处理结果的文件数，例如 "已覆盖目标 2, 目标已存在，已跳过 1"
+func FormatConflicts(counts map[ConflictOutcome]int) string {
+	var parts []string
+	for _, o := range ConflictOutcomes() {
+		if n := counts[o]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", o.Label(), n))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ErrTargetExists 冲突策略为fail时目标已存在的错误
+var ErrTargetExists = errors.New("目标文件已存在")
+
+// maxKeepBothIndex 保留两者时自动编号的上限
+const maxKeepBothIndex = 10000
+
+// resolveConflict 目标路径已存在时按任务的冲突策略决定最终的输出路径
+// 返回的outcome为ConflictSkipped时调用方不应处理该文件；
+// 为ConflictRenamed时返回的路径已被占位创建，处理失败时调用方需要删除
+func resolveConflict(ctx context.Context, t Task, newPath string) (string, ConflictOutcome, error) {
+	dstInfo, err := os.Lstat(newPath)
+	if os.IsNotExist(err) {
+		return newPath, ConflictNone, nil
+	}
+	if err != nil {
+		return "", ConflictNone, err
+	}
+	if dstInfo.IsDir() {
+		return "", ConflictFailed, fmt.Errorf("目标路径是目录: %s", newPath)
+	}
+
+	policy := t.OnConflict
+	if policy == "" {
+		policy = DefaultConflictPolicy
+	}
+	switch policy {
+	case OnConflictOverwrite:
+		return newPath, ConflictOverwritten, nil
+	case OnConflictSkip:
+		return newPath, ConflictSkipped, nil
+	case OnConflictKeepBoth:
+		path, err := reserveNumberedPath(newPath)
+		if err != nil {
+			return "", ConflictFailed, err
+		}
+		return path, ConflictRenamed, nil
+	case OnConflictNewer:
+		srcInfo, err := os.Stat(t.Path)
+		if err != nil {
+			return "", ConflictNone, err
+		}
+		if srcInfo.ModTime().After(dstInfo.ModTime()) {
+			return newPath, ConflictOverwritten, nil
+		}
+		return newPath, ConflictSkipped, nil
+	case OnConflictDifferent:
+		algos := []string{algorithmsOf(t.Algorithms)[0]}
+		srcHashes, err := calculateFileHashes(ctx, t.Path, algos)
+		if err != nil {
+			return "", ConflictNone, fmt.Errorf("计算源文件哈希失败: %w", err)
+		}
+		dstHashes, err := calculateFileHashes(ctx, newPath, algos)
+		if err != nil {
+			return "", ConflictNone, fmt.Errorf("计算目标文件哈希失败: %w", err)
+		}
+		if hashesEqual(srcHashes, dstHashes) {
+			return newPath, ConflictSkipped, nil
+		}
+		return newPath, ConflictOverwritten, nil
+	}
+	return "", ConflictFailed, fmt.Errorf("%w: %s", ErrTargetExists, newPath)
+}
+
+// reserveNumberedPath 生成 "name (1).ext" 形式的新路径，并以独占方式创建空文件占位，
+// 避免多个Worker选中同一个编号
+func reserveNumberedPath(path string) (string, error) {
+	dir := filepath.Dir(path)
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+	name := base[:len(base)-len(ext)]
+
+	for i := 1; i <= maxKeepBothIndex; i++ {
+		candidate := filepath.Join(dir, fmt.Sprintf("%s (%d)%s", name, i, ext))
+		f, err := createExclusive(candidate)
+		if err == nil {
+			f.Close()
+			return candidate, nil
+		}
+		if !os.IsExist(err) {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("无法为 %s 生成不重复的文件名", path)
+}
+
+// applyConflict 把冲突处理结果记录到Result，返回是否应继续处理该文件
+func applyConflict(result *Result, newPath string, outcome ConflictOutcome) bool {
+	result.Conflict = outcome
+	result.Replaced = outcome == ConflictOverwritten
+	if outcome == ConflictSkipped {
+		result.NewName = newPath
+		result.Skipped = true
+		return false
+	}
+	return true
+}
@@ -0,0 +1,126 @@
+package fileutil
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// benchFileSize 基准测试使用的源文件大小
+const benchFileSize = 64 << 20
+
+// writeRandomFile 生成指定大小的随机内容文件
+func writeRandomFile(b *testing.B, path string, size int64) {
+	b.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := io.CopyN(f, rand.Reader, size); err != nil {
+		b.Fatal(err)
+	}
+}
+
+// legacyFileMD5 旧流程计算文件MD5的方式
+func legacyFileMD5(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := md5.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+// legacyCopyFile 旧流程的复制：直接写入目标文件，同步到磁盘后复制权限
+func legacyCopyFile(src, dst string) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	dstFile, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer dstFile.Close()
+
+	if _, err := io.Copy(dstFile, srcFile); err != nil {
+		return err
+	}
+	if err := dstFile.Sync(); err != nil {
+		return err
+	}
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	return os.Chmod(dst, srcInfo.Mode())
+}
+
+// copyThreePass 旧的复制流程（与改动前的processCopy一致）：读源算MD5、复制、再读目标算MD5
+func copyThreePass(_ context.Context, src, dst string) error {
+	srcMD5, err := legacyFileMD5(src)
+	if err != nil {
+		return err
+	}
+	if err := legacyCopyFile(src, dst); err != nil {
+		return err
+	}
+	dstMD5, err := legacyFileMD5(dst)
+	if err != nil {
+		return err
+	}
+	if srcMD5 != dstMD5 {
+		return io.ErrShortWrite
+	}
+	return nil
+}
+
+func benchmarkCopy(b *testing.B, copyFn func(ctx context.Context, src, dst string) error) {
+	dir := b.TempDir()
+	src := filepath.Join(dir, "src.bin")
+	dst := filepath.Join(dir, "dst.bin")
+	writeRandomFile(b, src, benchFileSize)
+
+	ctx := context.Background()
+	b.SetBytes(benchFileSize)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := copyFn(ctx, src, dst); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkCopyThreePass 基线：源文件读两遍、目标文件读一遍
+func BenchmarkCopyThreePass(b *testing.B) {
+	benchmarkCopy(b, copyThreePass)
+}
+
+// BenchmarkCopyStreaming 边复制边计算MD5，只读一遍源文件
+func BenchmarkCopyStreaming(b *testing.B) {
+	benchmarkCopy(b, func(ctx context.Context, src, dst string) error {
+		_, _, _, err := copyFile(ctx, src, dst, nil, false, nil, nil)
+		return err
+	})
+}
+
+// BenchmarkCopyStrictVerify 边复制边计算MD5，并重新读取目标文件校验
+func BenchmarkCopyStrictVerify(b *testing.B) {
+	benchmarkCopy(b, func(ctx context.Context, src, dst string) error {
+		_, _, _, err := copyFile(ctx, src, dst, nil, true, nil, nil)
+		return err
+	})
+}
@@ -0,0 +1,216 @@
+package fileutil
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// DiffStatus 目录比较中单个相对路径的分类
+type DiffStatus string
+
+const (
+	DiffIdentical DiffStatus = "identical" // 内容相同
+	DiffDiffers   DiffStatus = "differs"   // 内容不同
+	DiffOnlyA     DiffStatus = "only_a"    // 仅存在于A
+	DiffOnlyB     DiffStatus = "only_b"    // 仅存在于B
+	DiffError     DiffStatus = "error"     // 无法读取，无法判断
+)
+
+// DiffStatuses 按显示顺序返回所有比较分类
+func DiffStatuses() []DiffStatus {
+	return []DiffStatus{DiffIdentical, DiffDiffers, DiffOnlyA, DiffOnlyB, DiffError}
+}
+
+// 判定依据
+const (
+	DiffBySize  = "size"       // 大小不同，未计算哈希
+	DiffByMtime = "size+mtime" // 大小和修改时间相同，未计算哈希（快速模式）
+	DiffByHash  = "hash"       // 比较哈希
+)
+
+// DiffEntry 单个相对路径的比较结果
+type DiffEntry struct {
+	Path   string     `json:"path"`             // 相对路径（使用/分隔）
+	Status DiffStatus `json:"status"`           // 分类
+	By     string     `json:"by,omitempty"`     // 判定依据
+	SizeA  int64      `json:"size_a"`           // A中的文件大小（不存在时为-1）
+	SizeB  int64      `json:"size_b"`           // B中的文件大小（不存在时为-1）
+	HashA  string     `json:"hash_a,omitempty"` // A中的文件哈希（计算过时）
+	HashB  string     `json:"hash_b,omitempty"` // B中的文件哈希（计算过时）
+	Err    string     `json:"error,omitempty"`  // 读取错误
+}
+
+// DiffOptions 目录比较参数
+type DiffOptions struct {
+	RootA     string // 目录A
+	RootB     string // 目录B
+	Algorithm string // 哈希算法（为空时使用默认算法）
+	Workers   int    // 并发Worker数
+	Quick     bool   // 大小和修改时间都相同时直接视为相同，不计算哈希
+}
+
+// DiffReport 目录比较报告
+type DiffReport struct {
+	RootA     string             `json:"root_a"`
+	RootB     string             `json:"root_b"`
+	Algorithm string             `json:"algorithm"`
+	Entries   []DiffEntry        `json:"entries"` // 按路径排序
+	Counts    map[DiffStatus]int `json:"counts"`
+}
+
+// DiffTrees 比较两个目录下所有文件：先按大小（快速模式下再按修改时间）短路，
+// 剩余的同名文件使用Worker Pool并行计算哈希后比较
+func DiffTrees(ctx context.Context, opts DiffOptions) (*DiffReport, error) {
+	algo := opts.Algorithm
+	if algo == "" {
+		algo = DefaultAlgorithm
+	}
+	if err := ValidateAlgorithms([]string{algo}); err != nil {
+		return nil, err
+	}
+
+	treeA, err := listTree(opts.RootA)
+	if err != nil {
+		return nil, fmt.Errorf("扫描目录A失败: %w", err)
+	}
+	treeB, err := listTree(opts.RootB)
+	if err != nil {
+		return nil, fmt.Errorf("扫描目录B失败: %w", err)
+	}
+
+	report := &DiffReport{RootA: opts.RootA, RootB: opts.RootB, Algorithm: algo, Counts: map[DiffStatus]int{}}
+	var pending []*DiffEntry
+	sides := map[string]diffSide{}
+	var files []string
+
+	for rel, infoA := range treeA {
+		entry := DiffEntry{Path: rel, SizeA: infoA.Size(), SizeB: -1}
+		infoB, ok := treeB[rel]
+		switch {
+		case !ok:
+			entry.Status = DiffOnlyA
+		case infoA.Size() != infoB.Size():
+			entry.Status, entry.By, entry.SizeB = DiffDiffers, DiffBySize, infoB.Size()
+		case opts.Quick && infoA.ModTime().Equal(infoB.ModTime()):
+			entry.Status, entry.By, entry.SizeB = DiffIdentical, DiffByMtime, infoB.Size()
+		default:
+			entry.By, entry.SizeB = DiffByHash, infoB.Size()
+			p := &entry
+			pending = append(pending, p)
+			pathA := filepath.Join(opts.RootA, filepath.FromSlash(rel))
+			pathB := filepath.Join(opts.RootB, filepath.FromSlash(rel))
+			sides[pathA] = diffSide{entry: p, isA: true}
+			sides[pathB] = diffSide{entry: p}
+			files = append(files, pathA, pathB)
+			continue
+		}
+		report.Entries = append(report.Entries, entry)
+	}
+	for rel, infoB := range treeB {
+		if _, ok := treeA[rel]; !ok {
+			report.Entries = append(report.Entries, DiffEntry{Path: rel, Status: DiffOnlyB, SizeA: -1, SizeB: infoB.Size()})
+		}
+	}
+
+	if len(files) > 0 {
+		if err := diffHashes(ctx, opts, algo, files, sides); err != nil {
+			return nil, err
+		}
+		for _, entry := range pending {
+			report.Entries = append(report.Entries, *entry)
+		}
+	}
+
+	sort.Slice(report.Entries, func(i, j int) bool { return report.Entries[i].Path < report.Entries[j].Path })
+	for _, e := range report.Entries {
+		report.Counts[e.Status]++
+	}
+	return report, nil
+}
+
+// diffSide 待计算哈希的文件对应的比较项，以及它属于哪个目录
+type diffSide struct {
+	entry *DiffEntry
+	isA   bool
+}
+
+// diffHashes 并行计算待比较文件的哈希并填写比较结果
+func diffHashes(ctx context.Context, opts DiffOptions, algo string, files []string, sides map[string]diffSide) error {
+	scheduler := NewScheduler(SchedulerConfig{
+		Mode:         "md5",
+		Workers:      opts.Workers,
+		Algorithms:   []string{algo},
+		Files:        files,
+		ErrorHandler: newSkipAllHandler(),
+	})
+	results, err := scheduler.Start(ctx)
+	if err != nil {
+		return err
+	}
+
+	for res := range results {
+		side, ok := sides[res.OldName]
+		if !ok {
+			continue
+		}
+		entry := side.entry
+		if res.Err != nil {
+			entry.Err = res.Err.Error()
+			continue
+		}
+		if side.isA {
+			entry.HashA = res.SrcHashes[algo]
+		} else {
+			entry.HashB = res.SrcHashes[algo]
+		}
+	}
+	if summary := scheduler.Wait(); summary.Interrupted {
+		return ctx.Err()
+	}
+
+	for _, side := range sides {
+		entry := side.entry
+		switch {
+		case entry.Err != "" || entry.HashA == "" || entry.HashB == "":
+			entry.Status = DiffError
+		case entry.HashA == entry.HashB:
+			entry.Status = DiffIdentical
+		default:
+			entry.Status = DiffDiffers
+		}
+	}
+	return nil
+}
+
+// listTree 列出目录下所有普通文件，键为/分隔的相对路径
+func listTree(root string) (map[string]fs.FileInfo, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s 不是目录", root)
+	}
+
+	tree := map[string]fs.FileInfo{}
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !d.Type().IsRegular() || isTempFile(d.Name()) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, err := manifestRelPath(root, path)
+		if err != nil {
+			return nil
+		}
+		tree[rel] = info
+		return nil
+	})
+	return tree, err
+}
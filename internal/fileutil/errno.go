@@ -0,0 +1,14 @@
+//go:build !plan9
+
+package fileutil
+
+import "syscall"
+
+// 按错误码分类时使用的系统错误码（plan9没有这些错误码，见errno_plan9.go）
+var (
+	errNoSpace       error = syscall.ENOSPC // 磁盘空间不足
+	errQuota         error = syscall.EDQUOT // 超出磁盘配额
+	errCrossDevice   error = syscall.EXDEV  // 跨文件系统重命名
+	errReadOnlyFS    error = syscall.EROFS  // 只读文件系统
+	errFileTableFull error = syscall.ENFILE // 系统打开的文件数达到上限
+)
@@ -0,0 +1,8 @@
+//go:build !js && !plan9
+
+package fileutil
+
+import "syscall"
+
+// busyErrnos 表示文件正被使用的系统错误码
+var busyErrnos = []error{syscall.ETXTBSY, syscall.EBUSY}
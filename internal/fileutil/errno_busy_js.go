@@ -0,0 +1,8 @@
+//go:build js
+
+package fileutil
+
+import "syscall"
+
+// busyErrnos 表示文件正被使用的系统错误码（js/wasm下没有ETXTBSY）
+var busyErrnos = []error{syscall.EBUSY}
@@ -0,0 +1,20 @@
+//go:build plan9
+
+package fileutil
+
+import (
+	"errors"
+	"syscall"
+)
+
+// plan9的系统错误没有对应的错误码，使用不会出现在错误链中的占位错误，这些错误归为其他类型
+var (
+	errNoSpace       = errors.New("no space left on device")
+	errQuota         = errors.New("disk quota exceeded")
+	errCrossDevice   = errors.New("cross-device link")
+	errReadOnlyFS    = errors.New("read-only file system")
+	errFileTableFull = errors.New("file table overflow")
+)
+
+// busyErrnos 表示文件正被使用的系统错误（plan9下没有ETXTBSY）
+var busyErrnos = []error{syscall.EBUSY}
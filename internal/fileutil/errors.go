@@ -0,0 +1,130 @@
+package fileutil
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// ErrorType 定义错误类型
+type ErrorType int
+
+const (
+	ErrorFileNotFound ErrorType = iota
+	ErrorPermissionDenied
+	ErrorDiskSpaceFull
+	ErrorIORead
+	ErrorIOWrite
+	ErrorCrossDevice
+	ErrorUnknown
+	ErrorTargetExists // 冲突策略为fail时目标已存在
+	ErrorReadOnlyFS   // 目标位于只读文件系统（EROFS）
+	ErrorTooManyFiles // 打开的文件过多（EMFILE/ENFILE）
+	ErrorNameTooLong  // 文件名或路径过长（ENAMETOOLONG）
+	ErrorFileBusy     // 文件正被使用（ETXTBSY/EBUSY）
+)
+
+// errorTypeLabels 错误类型的显示名称
+var errorTypeLabels = map[ErrorType]string{
+	ErrorFileNotFound:     "文件不存在",
+	ErrorPermissionDenied: "权限不足",
+	ErrorDiskSpaceFull:    "磁盘空间不足",
+	ErrorIORead:           "读取错误",
+	ErrorIOWrite:          "写入错误",
+	ErrorCrossDevice:      "跨设备错误",
+	ErrorUnknown:          "未知错误",
+	ErrorTargetExists:     "目标已存在",
+	ErrorReadOnlyFS:       "只读文件系统",
+	ErrorTooManyFiles:     "打开文件过多",
+	ErrorNameTooLong:      "文件名过长",
+	ErrorFileBusy:         "文件被占用",
+}
+
+// ErrorTypes 按显示顺序返回所有错误类型（用于界面按类型设置策略）
+func ErrorTypes() []ErrorType {
+	return []ErrorType{
+		ErrorFileNotFound, ErrorPermissionDenied, ErrorDiskSpaceFull, ErrorReadOnlyFS,
+		ErrorIORead, ErrorIOWrite, ErrorCrossDevice, ErrorTooManyFiles, ErrorNameTooLong,
+		ErrorFileBusy, ErrorTargetExists, ErrorUnknown,
+	}
+}
+
+// Label 返回错误类型的显示名称
+func (t ErrorType) Label() string {
+	if label, ok := errorTypeLabels[t]; ok {
+		return label
+	}
+	return fmt.Sprintf("错误类型%d", int(t))
+}
+
+// ErrorInfo 定义错误信息结构
+type ErrorInfo struct {
+	Type    ErrorType
+	Message string
+	Path    string
+	Err     error // 原始错误
+}
+
+// analyzeError 分析错误类型：按错误链中的系统错误码（syscall.Errno）和
+// *fs.PathError / *os.LinkError 的操作分类，不依赖错误信息的文字
+func analyzeError(err error, path string) ErrorInfo {
+	t := classifyError(err)
+	return ErrorInfo{
+		Type:    t,
+		Message: fmt.Sprintf("%s: %v", t.Label(), err),
+		Path:    path,
+		Err:     err,
+	}
+}
+
+// classifyError 返回错误链对应的错误类型
+func classifyError(err error) ErrorType {
+	switch {
+	case errors.Is(err, ErrTargetExists):
+		return ErrorTargetExists
+	case errors.Is(err, fs.ErrNotExist):
+		return ErrorFileNotFound
+	case errors.Is(err, errNoSpace), errors.Is(err, errQuota):
+		return ErrorDiskSpaceFull
+	case errors.Is(err, errCrossDevice):
+		return ErrorCrossDevice
+	case errors.Is(err, errReadOnlyFS):
+		return ErrorReadOnlyFS
+	case errors.Is(err, fs.ErrPermission):
+		return ErrorPermissionDenied
+	case errors.Is(err, syscall.EMFILE), errors.Is(err, errFileTableFull):
+		return ErrorTooManyFiles
+	case errors.Is(err, syscall.ENAMETOOLONG):
+		return ErrorNameTooLong
+	}
+	for _, errno := range busyErrnos {
+		if errors.Is(err, errno) {
+			return ErrorFileBusy
+		}
+	}
+
+	// 其他文件系统错误（如EIO）按出错的操作区分读写
+	var linkErr *os.LinkError
+	if errors.As(err, &linkErr) {
+		return ErrorIOWrite
+	}
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		switch pathErr.Op {
+		case "write", "sync", "close", "truncate", "create", "createtemp", "mkdir", "remove", "chmod", "chtimes", "chown":
+			return ErrorIOWrite
+		}
+		return ErrorIORead
+	}
+	if errors.Is(err, syscall.EIO) {
+		return ErrorIORead
+	}
+	return ErrorUnknown
+}
+
+// AnalyzeError 分析错误类型（导出版本）
+func AnalyzeError(err error, path string) ErrorInfo {
+	return analyzeError(err, path)
+}
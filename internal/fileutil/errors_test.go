@@ -0,0 +1,96 @@
+//go:build !js && !plan9
+
+package fileutil
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// TestAnalyzeError 分类结果只取决于错误链中的错误码和操作，与包装文字和路径无关
+func TestAnalyzeError(t *testing.T) {
+	dir := t.TempDir()
+
+	// 真实的文件系统错误
+	_, notFound := os.Open(filepath.Join(dir, "missing.txt"))
+	_, readNamedPath := os.Open(filepath.Join(dir, "read_write_cross-device", "missing.txt"))
+	_, tooLong := os.Open(filepath.Join(dir, strings.Repeat("a", 300)))
+	_, isDir := os.ReadFile(dir)
+
+	readOnly, err := os.Create(filepath.Join(dir, "ro.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	readOnly.Close()
+	if readOnly, err = os.Open(readOnly.Name()); err != nil {
+		t.Fatal(err)
+	}
+	_, badWrite := readOnly.Write([]byte("x"))
+	readOnly.Close()
+
+	// 在目录上创建文件、在普通文件下创建临时文件，标准库只报告操作名和错误码
+	_, createOnDir := createExclusive(dir)
+	_, createTempUnderFile := createTempFile(filepath.Join(readOnly.Name(), "dst.txt"))
+
+	// 难以在测试环境中触发的错误码，注入到与标准库相同的错误结构中
+	pathErr := func(op string, errno syscall.Errno) error {
+		return &fs.PathError{Op: op, Path: filepath.Join(dir, "file.txt"), Err: errno}
+	}
+	linkErr := func(errno syscall.Errno) error {
+		return &os.LinkError{Op: "rename", Old: filepath.Join(dir, "a"), New: filepath.Join(dir, "b"), Err: errno}
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want ErrorType
+	}{
+		{"文件不存在", notFound, ErrorFileNotFound},
+		{"MD5包装的文件不存在", fmt.Errorf("计算源文件MD5失败: %w", notFound), ErrorFileNotFound},
+		{"路径包含read/write的文件不存在", readNamedPath, ErrorFileNotFound},
+		{"文件名过长", tooLong, ErrorNameTooLong},
+		{"读取目录", isDir, ErrorIORead},
+		{"写入只读打开的文件", badWrite, ErrorIOWrite},
+		{"独占创建已存在的目录", createOnDir, ErrorIOWrite},
+		{"在普通文件下创建临时文件", createTempUnderFile, ErrorIOWrite},
+		{"MD5包装的权限不足", fmt.Errorf("计算源文件MD5失败: %w", pathErr("open", syscall.EACCES)), ErrorPermissionDenied},
+		{"EPERM", pathErr("chmod", syscall.EPERM), ErrorPermissionDenied},
+		{"磁盘空间不足", fmt.Errorf("复制文件失败: %w", pathErr("write", syscall.ENOSPC)), ErrorDiskSpaceFull},
+		{"跨设备重命名", linkErr(syscall.EXDEV), ErrorCrossDevice},
+		{"只读文件系统", pathErr("open", syscall.EROFS), ErrorReadOnlyFS},
+		{"打开文件过多", pathErr("open", syscall.EMFILE), ErrorTooManyFiles},
+		{"系统打开文件过多", pathErr("open", syscall.ENFILE), ErrorTooManyFiles},
+		{"读取时EIO", pathErr("read", syscall.EIO), ErrorIORead},
+		{"读取时打开EIO", pathErr("open", syscall.EIO), ErrorIORead},
+		{"创建时EIO", pathErr("create", syscall.EIO), ErrorIOWrite},
+		{"创建临时文件时EIO", fmt.Errorf("创建临时文件失败: %w", pathErr("createtemp", syscall.EIO)), ErrorIOWrite},
+		{"写入时EIO", pathErr("write", syscall.EIO), ErrorIOWrite},
+		{"关闭时EIO", pathErr("close", syscall.EIO), ErrorIOWrite},
+		{"重命名时EIO", linkErr(syscall.EIO), ErrorIOWrite},
+		{"文件被占用", pathErr("open", syscall.ETXTBSY), ErrorFileBusy},
+		{"设备忙", linkErr(syscall.EBUSY), ErrorFileBusy},
+		{"目标已存在", fmt.Errorf("重命名 a 失败: %w", ErrTargetExists), ErrorTargetExists},
+		{"无错误码的read文字", errors.New("read failed: cross-device link"), ErrorUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.err == nil {
+				t.Fatal("没有得到预期的错误")
+			}
+			info := AnalyzeError(tt.err, "file.txt")
+			if info.Type != tt.want {
+				t.Errorf("AnalyzeError(%v) = %s, 期望 %s", tt.err, info.Type.Label(), tt.want.Label())
+			}
+			if info.Path != "file.txt" || !strings.Contains(info.Message, tt.err.Error()) {
+				t.Errorf("ErrorInfo = %+v", info)
+			}
+		})
+	}
+}
@@ -0,0 +1,390 @@
+package fileutil
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FilterReason 文件被扫描筛选排除的原因
+type FilterReason string
+
+const (
+	FilteredHidden  FilterReason = "hidden"  // 隐藏文件或位于隐藏目录中
+	FilteredIgnore  FilterReason = "ignore"  // 匹配忽略文件中的规则
+	FilteredInclude FilterReason = "include" // 不匹配任何包含规则
+	FilteredExclude FilterReason = "exclude" // 匹配排除规则
+	FilteredSize    FilterReason = "size"    // 大小不在范围内
+	FilteredMtime   FilterReason = "mtime"   // 修改时间不在范围内
+)
+
+// filterReasonLabels 排除原因的显示名称
+var filterReasonLabels = map[FilterReason]string{
+	FilteredHidden:  "隐藏文件",
+	FilteredIgnore:  "忽略文件规则",
+	FilteredInclude: "不匹配包含规则",
+	FilteredExclude: "匹配排除规则",
+	FilteredSize:    "大小不在范围内",
+	FilteredMtime:   "修改时间不在范围内",
+}
+
+// FilterReasons 按判断顺序返回所有排除原因（文件只计入第一个不满足的筛选条件）
+func FilterReasons() []FilterReason {
+	return []FilterReason{FilteredHidden, FilteredIgnore, FilteredInclude, FilteredExclude, FilteredSize, FilteredMtime}
+}
+
+// Label 返回排除原因的显示名称
+func (r FilterReason) Label() string {
+	if label, ok := filterReasonLabels[r]; ok {
+		return label
+	}
+	return string(r)
+}
+
+// FormatFiltered 格式化各筛选条件排除的文件数，例如 "隐藏文件 3, 匹配排除规则 12"
+func FormatFiltered(counts map[FilterReason]int) string {
+	var parts []string
+	for _, r := range FilterReasons() {
+		if n := counts[r]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", r.Label(), n))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Filter 扫描源目录时的筛选条件（可保存为JSON筛选配置文件）
+// 通配符规则使用 / 分隔的相对路径：不含 / 的规则匹配任意层级的文件名或目录名（如 *.log），
+// 含 / 的规则匹配相对源目录的完整路径，** 匹配任意层目录（如 **/subdir/**）；匹配目录时作用于其中的所有文件
+type Filter struct {
+	Include        []string  `json:"include,omitempty"`        // 包含规则（设置后只处理匹配任一规则的文件）
+	Exclude        []string  `json:"exclude,omitempty"`        // 排除规则
+	MinSize        int64     `json:"min_size,omitempty"`       // 最小文件大小（字节）
+	MaxSize        int64     `json:"max_size,omitempty"`       // 最大文件大小（字节，0表示不限）
+	ModifiedAfter  time.Time `json:"modified_after,omitzero"`  // 只处理此时间及之后修改的文件
+	ModifiedBefore time.Time `json:"modified_before,omitzero"` // 只处理此时间之前修改的文件
+	SkipHidden     bool      `json:"skip_hidden,omitempty"`    // 跳过以.开头的文件和目录
+	IgnoreFile     string    `json:"ignore_file,omitempty"`    // .gitignore格式的忽略文件（相对路径相对于源目录）
+}
+
+// IsZero 判断是否未设置任何筛选条件
+func (f Filter) IsZero() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0 && f.MinSize == 0 && f.MaxSize == 0 &&
+		f.ModifiedAfter.IsZero() && f.ModifiedBefore.IsZero() && !f.SkipHidden && f.IgnoreFile == ""
+}
+
+// LoadFilter 读取筛选配置文件
+func LoadFilter(path string) (*Filter, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f := &Filter{}
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("解析筛选配置失败: %w", err)
+	}
+	return f, nil
+}
+
+// Save 原子写出筛选配置文件（JSON）
+func (f *Filter) Save(path string) error {
+	return writeFileAtomic(path, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(f)
+	})
+}
+
+// ScanFilter 编译后的扫描筛选条件，记录各条件排除的文件数
+type ScanFilter struct {
+	filter Filter
+	ignore []ignoreRule
+	counts map[FilterReason]int
+}
+
+// Compile 校验筛选条件，读取忽略文件（相对路径相对于root）
+func (f Filter) Compile(root string) (*ScanFilter, error) {
+	for _, list := range [][]string{f.Include, f.Exclude} {
+		for _, p := range list {
+			if err := checkGlob(p); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if f.MinSize < 0 || f.MaxSize < 0 {
+		return nil, fmt.Errorf("文件大小范围不能为负数")
+	}
+	if f.MaxSize > 0 && f.MinSize > f.MaxSize {
+		return nil, fmt.Errorf("最小文件大小不能大于最大文件大小")
+	}
+	if !f.ModifiedAfter.IsZero() && !f.ModifiedBefore.IsZero() && !f.ModifiedAfter.Before(f.ModifiedBefore) {
+		return nil, fmt.Errorf("修改时间范围无效：起始时间应早于截止时间")
+	}
+
+	sf := &ScanFilter{filter: f, counts: map[FilterReason]int{}}
+	if f.IgnoreFile != "" {
+		ignorePath := f.IgnoreFile
+		if !filepath.IsAbs(ignorePath) {
+			ignorePath = filepath.Join(root, ignorePath)
+		}
+		rules, err := readIgnoreFile(ignorePath)
+		if err != nil {
+			return nil, fmt.Errorf("读取忽略文件失败: %w", err)
+		}
+		sf.ignore = rules
+	}
+	return sf, nil
+}
+
+// Counts 返回各筛选条件排除的文件数（整个被排除的目录计为一项）
+func (sf *ScanFilter) Counts() map[FilterReason]int {
+	return sf.counts
+}
+
+// skipDir 判断目录（rel为相对源目录的路径）是否整个被排除，被排除时计入对应的排除原因，遍历时不再进入该目录
+// 隐藏、忽略和排除规则作用于目录时其中的文件都被排除，与逐个检查文件的结果相同
+func (sf *ScanFilter) skipDir(rel string) bool {
+	rel = filepath.ToSlash(rel)
+	var reason FilterReason
+	switch f := sf.filter; {
+	case f.SkipHidden && isHiddenPath(rel):
+		reason = FilteredHidden
+	case len(sf.ignore) > 0 && matchIgnore(sf.ignore, strings.Split(rel, "/"), true):
+		reason = FilteredIgnore
+	case matchAnyGlob(f.Exclude, rel):
+		reason = FilteredExclude
+	default:
+		return false
+	}
+	sf.counts[reason]++
+	return true
+}
+
+// check 判断文件是否通过筛选（rel为相对源目录的路径），未通过时计入对应的排除原因
+func (sf *ScanFilter) check(rel string, info os.FileInfo) bool {
+	reason := sf.reject(filepath.ToSlash(rel), info)
+	if reason == "" {
+		return true
+	}
+	sf.counts[reason]++
+	return false
+}
+
+// reject 返回文件未通过的第一个筛选条件，通过时返回空
+func (sf *ScanFilter) reject(rel string, info os.FileInfo) FilterReason {
+	f := sf.filter
+	if f.SkipHidden && isHiddenPath(rel) {
+		return FilteredHidden
+	}
+	if len(sf.ignore) > 0 && ignored(sf.ignore, rel) {
+		return FilteredIgnore
+	}
+	if len(f.Include) > 0 && !matchAnyGlob(f.Include, rel) {
+		return FilteredInclude
+	}
+	if matchAnyGlob(f.Exclude, rel) {
+		return FilteredExclude
+	}
+	if size := info.Size(); size < f.MinSize || (f.MaxSize > 0 && size > f.MaxSize) {
+		return FilteredSize
+	}
+	mtime := info.ModTime()
+	if (!f.ModifiedAfter.IsZero() && mtime.Before(f.ModifiedAfter)) ||
+		(!f.ModifiedBefore.IsZero() && !mtime.Before(f.ModifiedBefore)) {
+		return FilteredMtime
+	}
+	return ""
+}
+
+// isHiddenPath 判断相对路径中的文件或任一级目录是否以.开头
+func isHiddenPath(rel string) bool {
+	for _, part := range strings.Split(rel, "/") {
+		if strings.HasPrefix(part, ".") && part != "." && part != ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// checkGlob 校验通配符规则的语法
+func checkGlob(pattern string) error {
+	if strings.TrimSpace(pattern) == "" {
+		return fmt.Errorf("通配符规则不能为空")
+	}
+	for _, seg := range strings.Split(strings.Trim(pattern, "/"), "/") {
+		if _, err := path.Match(seg, ""); err != nil {
+			return fmt.Errorf("通配符规则语法错误: %s", pattern)
+		}
+	}
+	return nil
+}
+
+// matchAnyGlob 判断相对路径或其任一级父目录是否匹配任一通配符规则
+// （规则匹配目录时作用于其中的所有文件，如 --exclude node_modules）
+func matchAnyGlob(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		for dir := rel; dir != "."; dir = path.Dir(dir) {
+			if matchGlob(p, dir) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchGlob 按通配符规则匹配相对路径：不含 / 的规则匹配文件名（结尾的 / 可省略），否则匹配完整路径
+func matchGlob(pattern, rel string) bool {
+	if name := strings.TrimSuffix(pattern, "/"); !strings.Contains(name, "/") {
+		ok, _ := path.Match(name, path.Base(rel))
+		return ok
+	}
+	return matchSegments(strings.Split(strings.Trim(pattern, "/"), "/"), strings.Split(rel, "/"))
+}
+
+// matchSegments 逐级匹配路径，** 匹配零到任意层
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			pattern = pattern[1:]
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// ignoreRule 忽略文件中的一条规则
+type ignoreRule struct {
+	pattern []string // 按 / 拆分的规则（不含 / 的规则前面补 **，匹配任意层级）
+	negate  bool     // 以 ! 开头：重新包含之前被忽略的文件
+	dirOnly bool     // 以 / 结尾：只匹配目录
+}
+
+// readIgnoreFile 读取.gitignore格式的忽略文件：# 开头为注释，! 取反，
+// 以 / 结尾只匹配目录，含 / 的规则相对源目录，否则匹配任意层级
+func readIgnoreFile(path string) ([]ignoreRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if !strings.Contains(line, "/") {
+			line = "**/" + line
+		}
+		line = strings.TrimPrefix(line, "/")
+		if line == "" {
+			continue
+		}
+		if err := checkGlob(line); err != nil {
+			return nil, fmt.Errorf("第%d行: %w", lineNo, err)
+		}
+		rule.pattern = strings.Split(line, "/")
+		rules = append(rules, rule)
+	}
+	return rules, scanner.Err()
+}
+
+// ignored 判断相对路径是否被忽略：任一级父目录被忽略时其中的文件都被忽略（不能再用!包含），
+// 否则以最后一条匹配文件本身的规则为准
+func ignored(rules []ignoreRule, rel string) bool {
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		if matchIgnore(rules, parts[:i], true) {
+			return true
+		}
+	}
+	return matchIgnore(rules, parts, false)
+}
+
+// matchIgnore 按规则顺序判断路径是否被忽略（后面的规则覆盖前面的）
+func matchIgnore(rules []ignoreRule, parts []string, isDir bool) bool {
+	result := false
+	for _, r := range rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		if matchSegments(r.pattern, parts) {
+			result = !r.negate
+		}
+	}
+	return result
+}
+
+// ParseSize 解析文件大小，支持 B/K/M/G/T 后缀（1024进制，可带 B 或 iB，如 10M、1.5GiB、4096）
+func ParseSize(s string) (int64, error) {
+	text := strings.ToUpper(strings.TrimSpace(s))
+	text = strings.TrimSuffix(strings.TrimSuffix(text, "IB"), "B")
+	multiplier := int64(1)
+	if text != "" {
+		if i := strings.IndexByte("KMGT", text[len(text)-1]); i >= 0 {
+			multiplier = int64(1) << (10 * (i + 1))
+			text = text[:len(text)-1]
+		}
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("无效的文件大小: %s（示例：4096、10K、1.5M、2G）", s)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// timeLayouts ParseTimeBound支持的时间格式（不含时区的按本地时间解析）
+var timeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+// ParseTimeBound 解析修改时间条件：日期时间（如 2024-01-31、2024-01-31 08:00、RFC3339），
+// 或相对于now的时长（如 90m、24h、7d 表示now之前的该时长）
+func ParseTimeBound(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	} else if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("无效的时间: %s（示例：2024-01-31、2024-01-31 08:00、24h、7d）", s)
+}
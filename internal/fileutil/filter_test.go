@@ -0,0 +1,232 @@
+package fileutil
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMatchGlob 不含 / 的规则匹配文件名，含 / 的规则匹配完整路径，** 匹配零到任意层目录
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		rel     string
+		want    bool
+	}{
+		{"文件名任意层级", "*.log", "a/b/x.log", true},
+		{"文件名不匹配", "*.log", "x.txt", false},
+		{"以/结尾的目录名", "build/", "build", true},
+		{"完整路径", "src/*.go", "src/a.go", true},
+		{"完整路径不跨目录", "src/*.go", "src/sub/a.go", false},
+		{"开头的/", "/docs/*.md", "docs/readme.md", true},
+		{"两端的**", "**/node_modules/**", "a/node_modules/x/y.js", true},
+		{"两端的**匹配目录本身", "**/node_modules/**", "node_modules", true},
+		{"开头的**匹配零层", "**/*.tmp", "x.tmp", true},
+		{"中间的**匹配零层", "a/**/b", "a/b", true},
+		{"中间的**匹配多层", "a/**/b", "a/x/y/b", true},
+		{"中间的**后不匹配", "a/**/b", "a/x/c", false},
+		{"结尾的**", "a/**", "a/x/y", true},
+		{"结尾的**不匹配其他目录", "a/**", "b/x", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchGlob(tt.pattern, tt.rel); got != tt.want {
+				t.Errorf("matchGlob(%q, %q) = %v, 期望 %v", tt.pattern, tt.rel, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestIgnored 以最后一条匹配的规则为准，父目录被忽略时其中的文件不能再用!包含
+func TestIgnored(t *testing.T) {
+	dir := t.TempDir()
+	ignoreFile := filepath.Join(dir, ".ignore")
+	content := strings.Join([]string{
+		"# 注释",
+		"*.log",
+		"!keep.log",
+		"build/",
+		"/root-only.txt",
+		"docs/**/*.tmp",
+		`\#hash.txt`,
+		"",
+	}, "\n")
+	if err := os.WriteFile(ignoreFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := readIgnoreFile(ignoreFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		rel  string
+		want bool
+	}{
+		{"任意层级的文件名", "sub/a.log", true},
+		{"取反重新包含", "keep.log", false},
+		{"子目录中取反重新包含", "sub/keep.log", false},
+		{"只匹配目录的规则作用于其中的文件", "build/x.txt", true},
+		{"只匹配目录的规则不匹配同名文件", "build", false},
+		{"父目录被忽略时不能取反", "build/keep.log", true},
+		{"开头的/只匹配源目录下", "root-only.txt", true},
+		{"开头的/不匹配子目录", "sub/root-only.txt", false},
+		{"**匹配多层", "docs/a/b/x.tmp", true},
+		{"**匹配零层", "docs/x.tmp", true},
+		{"含/的规则相对源目录", "x.tmp", false},
+		{"转义的#", "#hash.txt", true},
+		{"注释不是规则", "# 注释", false},
+		{"不匹配任何规则", "a.txt", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ignored(rules, tt.rel); got != tt.want {
+				t.Errorf("ignored(%q) = %v, 期望 %v", tt.rel, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestReadIgnoreFileError 忽略文件中的语法错误报告行号
+func TestReadIgnoreFileError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".ignore")
+	if err := os.WriteFile(path, []byte("*.log\n[\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readIgnoreFile(path); err == nil || !strings.Contains(err.Error(), "第2行") {
+		t.Errorf("readIgnoreFile() 错误 = %v, 期望包含 %q", err, "第2行")
+	}
+}
+
+// TestWalkFilesFilter 整个被排除的目录不再进入并计为一项，包含规则不会跳过目录
+func TestWalkFilesFilter(t *testing.T) {
+	root := t.TempDir()
+	for _, rel := range []string{
+		"a.txt", "a.log", "keep.log", ".ignore", ".git/config",
+		"node_modules/x.js", "node_modules/sub/y.js", "src/b.go", "src/build/out.o",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(rel), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ignore := "build/\n*.log\n!keep.log\nnode_modules/\n"
+	if err := os.WriteFile(filepath.Join(root, ".ignore"), []byte(ignore), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+		counts map[FilterReason]int
+	}{
+		{
+			name:   "排除目录",
+			filter: Filter{Exclude: []string{"node_modules"}},
+			want:   []string{".git/config", ".ignore", "a.log", "a.txt", "keep.log", "src/b.go", "src/build/out.o"},
+			counts: map[FilterReason]int{FilteredExclude: 1},
+		},
+		{
+			name:   "以/结尾的排除规则",
+			filter: Filter{Exclude: []string{"build/"}},
+			want:   []string{".git/config", ".ignore", "a.log", "a.txt", "keep.log", "node_modules/sub/y.js", "node_modules/x.js", "src/b.go"},
+			counts: map[FilterReason]int{FilteredExclude: 1},
+		},
+		{
+			name:   "**排除规则",
+			filter: Filter{Exclude: []string{"**/build/**"}},
+			want:   []string{".git/config", ".ignore", "a.log", "a.txt", "keep.log", "node_modules/sub/y.js", "node_modules/x.js", "src/b.go"},
+			counts: map[FilterReason]int{FilteredExclude: 1},
+		},
+		{
+			name:   "隐藏目录",
+			filter: Filter{SkipHidden: true},
+			want:   []string{"a.log", "a.txt", "keep.log", "node_modules/sub/y.js", "node_modules/x.js", "src/b.go", "src/build/out.o"},
+			counts: map[FilterReason]int{FilteredHidden: 2},
+		},
+		{
+			name:   "忽略文件",
+			filter: Filter{IgnoreFile: ".ignore"},
+			want:   []string{".git/config", ".ignore", "a.txt", "keep.log", "src/b.go"},
+			counts: map[FilterReason]int{FilteredIgnore: 3},
+		},
+		{
+			name:   "包含规则",
+			filter: Filter{Include: []string{"**/*.go"}},
+			want:   []string{"src/b.go"},
+			counts: map[FilterReason]int{FilteredInclude: 8},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sf, err := tt.filter.Compile(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			files, unreadable, err := scanFiles(root, nil, sf)
+			if err != nil || unreadable != 0 {
+				t.Fatalf("scanFiles() 无法读取 %d 项, 错误: %v", unreadable, err)
+			}
+			var got []string
+			for _, f := range files {
+				rel, err := filepath.Rel(root, f)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, filepath.ToSlash(rel))
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("扫描到的文件 = %v, 期望 %v", got, tt.want)
+			}
+			if !maps.Equal(sf.Counts(), tt.counts) {
+				t.Errorf("Counts() = %v, 期望 %v", sf.Counts(), tt.counts)
+			}
+		})
+	}
+}
+
+// TestFilterCompile 校验通配符语法、大小范围和时间范围
+func TestFilterCompile(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  Filter
+		wantErr string
+	}{
+		{"有效的规则", Filter{Include: []string{"**/*.go"}, Exclude: []string{"vendor/"}}, ""},
+		{"空规则", Filter{Exclude: []string{" "}}, "不能为空"},
+		{"语法错误", Filter{Include: []string{"a/[b"}}, "语法错误"},
+		{"负数大小", Filter{MinSize: -1}, "不能为负数"},
+		{"最小大于最大", Filter{MinSize: 10, MaxSize: 5}, "不能大于"},
+		{"时间范围无效", Filter{ModifiedAfter: time.Unix(200, 0), ModifiedBefore: time.Unix(100, 0)}, "起始时间应早于截止时间"},
+		{"忽略文件不存在", Filter{IgnoreFile: "missing"}, "读取忽略文件失败"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.filter.Compile(t.TempDir())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Compile() 返回错误: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Compile() 错误 = %v, 期望包含 %q", err, tt.wantErr)
+			}
+		})
+	}
+}
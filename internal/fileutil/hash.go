@@ -0,0 +1,171 @@
+package fileutil
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/cespare/xxhash/v2"
+	"golang.org/x/crypto/blake2b"
+)
+
+// 支持的哈希算法名称
+const (
+	HashMD5     = "md5"
+	HashSHA1    = "sha1"
+	HashSHA256  = "sha256"
+	HashSHA512  = "sha512"
+	HashBLAKE2b = "blake2b" // BLAKE2b-512，与b2sum默认输出一致
+	HashCRC32C  = "crc32c"  // CRC-32 Castagnoli，非加密哈希
+	HashXXHash  = "xxhash"  // xxHash64，非加密哈希
+)
+
+// DefaultAlgorithm 未指定算法时使用的哈希算法
+const DefaultAlgorithm = HashMD5
+
+// hashAlgorithms 按显示顺序排列的哈希算法
+var hashAlgorithms = []struct {
+	name string
+	new  func() hash.Hash
+}{
+	{HashMD5, md5.New},
+	{HashSHA1, sha1.New},
+	{HashSHA256, sha256.New},
+	{HashSHA512, sha512.New},
+	{HashBLAKE2b, func() hash.Hash {
+		h, _ := blake2b.New512(nil)
+		return h
+	}},
+	{HashCRC32C, func() hash.Hash {
+		return crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	}},
+	{HashXXHash, func() hash.Hash {
+		return xxhash.New()
+	}},
+}
+
+// HashAlgorithms 返回所有支持的哈希算法名称
+func HashAlgorithms() []string {
+	names := make([]string, 0, len(hashAlgorithms))
+	for _, a := range hashAlgorithms {
+		names = append(names, a.name)
+	}
+	return names
+}
+
+// NewHash 按算法名称创建哈希计算器
+func NewHash(name string) (hash.Hash, error) {
+	for _, a := range hashAlgorithms {
+		if a.name == name {
+			return a.new(), nil
+		}
+	}
+	return nil, fmt.Errorf("不支持的哈希算法: %s（可选：%s）", name, strings.Join(HashAlgorithms(), "/"))
+}
+
+// ValidateAlgorithms 校验算法列表（不能重复，必须是支持的算法）
+func ValidateAlgorithms(algos []string) error {
+	seen := map[string]bool{}
+	for _, name := range algos {
+		if _, err := NewHash(name); err != nil {
+			return err
+		}
+		if seen[name] {
+			return fmt.Errorf("哈希算法重复: %s", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// algorithmsOf 返回任务使用的算法列表，未指定时使用默认算法
+func algorithmsOf(algos []string) []string {
+	if len(algos) == 0 {
+		return []string{DefaultAlgorithm}
+	}
+	return algos
+}
+
+// multiHasher 在一次读取中同时计算多种哈希
+type multiHasher struct {
+	algos  []string
+	hashes []hash.Hash
+	io.Writer
+}
+
+// newMultiHasher 创建多算法哈希计算器
+func newMultiHasher(algos []string) (*multiHasher, error) {
+	algos = algorithmsOf(algos)
+	m := &multiHasher{algos: algos}
+	writers := make([]io.Writer, 0, len(algos))
+	for _, name := range algos {
+		h, err := NewHash(name)
+		if err != nil {
+			return nil, err
+		}
+		m.hashes = append(m.hashes, h)
+		writers = append(writers, h)
+	}
+	m.Writer = io.MultiWriter(writers...)
+	return m, nil
+}
+
+// Sums 返回各算法的十六进制摘要
+func (m *multiHasher) Sums() map[string]string {
+	sums := make(map[string]string, len(m.algos))
+	for i, name := range m.algos {
+		sums[name] = fmt.Sprintf("%x", m.hashes[i].Sum(nil))
+	}
+	return sums
+}
+
+// calculateFileHashes 一次读取计算文件的多种哈希，ctx取消时中断读取
+func calculateFileHashes(ctx context.Context, filePath string, algos []string) (map[string]string, error) {
+	hasher, err := newMultiHasher(algos)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(hasher, &contextReader{ctx: ctx, r: file}); err != nil {
+		return nil, err
+	}
+	return hasher.Sums(), nil
+}
+
+// hashesEqual 判断两组哈希在所有共同算法上是否一致
+func hashesEqual(a, b map[string]string) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return false
+	}
+	for name, sum := range a {
+		if other, ok := b[name]; ok && other != sum {
+			return false
+		}
+	}
+	return true
+}
+
+// FormatHashes 按算法顺序格式化哈希结果，例如 "MD5: xxx SHA256: yyy"
+func FormatHashes(sums map[string]string) string {
+	var parts []string
+	for _, name := range HashAlgorithms() {
+		if sum, ok := sums[name]; ok {
+			parts = append(parts, fmt.Sprintf("%s: %s", strings.ToUpper(name), sum))
+		}
+	}
+	return strings.Join(parts, " ")
+}
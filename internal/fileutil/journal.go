@@ -0,0 +1,286 @@
+package fileutil
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// journalVersion 断点文件格式版本
+const journalVersion = 1
+
+// 断点文件刷新条件：累计100个任务或距上次写入超过1秒，
+// 且距上次写入的时间不少于上次写入耗时的10倍（避免大文件频繁重写拖慢批处理）
+const (
+	journalFlushTasks    = 100
+	journalFlushInterval = time.Second
+	journalFlushCostRate = 10
+)
+
+// JournalStatus 断点文件中任务的状态
+type JournalStatus string
+
+const (
+	JournalDone   JournalStatus = "done"   // 已完成，续作时跳过
+	JournalFailed JournalStatus = "failed" // 失败或被跳过，续作时重新处理
+)
+
+// JournalEntry 断点文件中的单个任务记录
+type JournalEntry struct {
+	Status  JournalStatus `json:"status"`
+	Hash    string        `json:"hash,omitempty"`     // 源文件哈希（使用Journal.Algorithm）
+	Size    int64         `json:"size"`               // 源文件大小
+	ModTime int64         `json:"mtime"`              // 源文件修改时间（Unix纳秒）
+	NewName string        `json:"new_name,omitempty"` // 输出文件的绝对路径
+	Error   string        `json:"error,omitempty"`    // 失败原因
+}
+
+// Journal 批处理断点文件：按源文件相对路径记录已完成和失败的任务
+type Journal struct {
+	Version    int                      `json:"version"`
+	Mode       string                   `json:"mode"`
+	SrcRoot    string                   `json:"src_root"`
+	DestRoot   string                   `json:"dest_root,omitempty"`
+	Prefix     string                   `json:"prefix,omitempty"`
+	Suffix     string                   `json:"suffix,omitempty"`
+	Template   string                   `json:"template,omitempty"`
+	Regex      *RegexRule               `json:"regex,omitempty"`
+	Transforms []string                 `json:"transforms,omitempty"`
+	Numbering  *Numbering               `json:"numbering,omitempty"`
+	Algorithm  string                   `json:"algorithm"`
+	Entries    map[string]*JournalEntry `json:"entries"` // 相对路径（/分隔） -> 任务记录
+
+	path      string
+	outputs   map[string]*JournalEntry // 输出文件绝对路径 -> 上次的记录（续作时建立）
+	mu        sync.Mutex
+	dirty     int
+	lastFlush time.Time
+	flushCost time.Duration
+}
+
+// DefaultJournalPath 返回批处理配置对应的默认断点文件路径（位于用户缓存目录）
+// 相同的模式、源目录、目标目录和重命名规则对应同一个断点文件
+func DefaultJournalPath(cfg SchedulerConfig) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("获取缓存目录失败: %w", err)
+	}
+	header, err := newJournal("", cfg)
+	if err != nil {
+		return "", err
+	}
+	key := fmt.Sprintf("%s\x00%s\x00%s\x00%s\x00%s", header.Mode, header.SrcRoot, header.DestRoot, header.Prefix, header.Suffix)
+	if header.Template != "" {
+		key += "\x00" + header.Template
+	}
+	if header.Regex != nil {
+		key += fmt.Sprintf("\x00%+v", *header.Regex)
+	}
+	if len(header.Transforms) > 0 {
+		key += "\x00" + strings.Join(header.Transforms, ",")
+	}
+	if header.Numbering != nil {
+		key += fmt.Sprintf("\x00%+v", *header.Numbering)
+	}
+	name := fmt.Sprintf("journal-%x.json", sha1.Sum([]byte(key)))
+	return filepath.Join(cacheDir, "filetool", name), nil
+}
+
+// newJournal 根据调度配置创建空的断点文件
+func newJournal(path string, cfg SchedulerConfig) (*Journal, error) {
+	j := &Journal{
+		Version:    journalVersion,
+		Mode:       cfg.Mode,
+		Prefix:     cfg.Prefix,
+		Suffix:     cfg.Suffix,
+		Template:   cfg.Template,
+		Regex:      cfg.Regex,
+		Transforms: cfg.Transforms,
+		Numbering:  cfg.Numbering,
+		Algorithm:  algorithmsOf(cfg.Algorithms)[0],
+		Entries:    map[string]*JournalEntry{},
+		path:       path,
+	}
+	var err error
+	if j.SrcRoot, err = filepath.Abs(cfg.SrcRoot); err != nil {
+		return nil, err
+	}
+	if cfg.DestRoot != "" {
+		if j.DestRoot, err = filepath.Abs(cfg.DestRoot); err != nil {
+			return nil, err
+		}
+	}
+	return j, nil
+}
+
+// openJournal 打开断点文件：续作时加载已有记录（参数必须与本次一致），否则新建
+func openJournal(path string, cfg SchedulerConfig, resume bool) (*Journal, error) {
+	j, err := newJournal(path, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if !resume {
+		return j, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取断点文件失败: %w", err)
+	}
+	loaded := &Journal{}
+	if err := json.Unmarshal(data, loaded); err != nil {
+		return nil, fmt.Errorf("解析断点文件失败: %w", err)
+	}
+	if loaded.Version != journalVersion {
+		return nil, fmt.Errorf("不支持的断点文件版本: %d", loaded.Version)
+	}
+	if loaded.Mode != j.Mode || loaded.SrcRoot != j.SrcRoot || loaded.DestRoot != j.DestRoot ||
+		loaded.Prefix != j.Prefix || loaded.Suffix != j.Suffix || loaded.Template != j.Template ||
+		!sameRegexRule(loaded.Regex, j.Regex) || strings.Join(loaded.Transforms, ",") != strings.Join(j.Transforms, ",") ||
+		!sameNumbering(loaded.Numbering, j.Numbering) {
+		return nil, fmt.Errorf("断点文件与本次任务的模式、目录或重命名规则不一致")
+	}
+	if loaded.Algorithm != j.Algorithm {
+		// 已完成记录中的哈希按上次的算法计算，无法用本次的算法判断文件是否变化
+		return nil, fmt.Errorf("断点文件使用的哈希算法（%s）与本次任务（%s）不一致", loaded.Algorithm, j.Algorithm)
+	}
+	if loaded.Entries != nil {
+		j.Entries = loaded.Entries
+	}
+	return j, nil
+}
+
+// Path 返回断点文件路径
+func (j *Journal) Path() string {
+	return j.path
+}
+
+// completed 判断文件是否已在上次完成，续作时跳过
+func (j *Journal) completed(ctx context.Context, path string) bool {
+	return j.lookup(ctx, path) != nil
+}
+
+// lookup 返回文件上次完成时的记录，未完成或文件已变化时返回nil：
+// 已完成的源文件、以及之前重命名/移动生成且仍在源目录中的输出文件都视为已完成
+func (j *Journal) lookup(ctx context.Context, path string) *JournalEntry {
+	j.mu.Lock()
+	if j.outputs == nil {
+		// 首次调用时按上次的记录建立输出文件索引（本次新增的记录不影响续作判断）
+		j.outputs = map[string]*JournalEntry{}
+		for _, e := range j.Entries {
+			if e.Status == JournalDone && e.NewName != "" {
+				j.outputs[e.NewName] = e
+			}
+		}
+	}
+	var entry *JournalEntry
+	if rel, err := manifestRelPath(j.SrcRoot, absPath(path)); err == nil {
+		if e, ok := j.Entries[rel]; ok && e.Status == JournalDone {
+			entry = e
+		}
+	}
+	if entry == nil {
+		entry = j.outputs[absPath(path)]
+	}
+	j.mu.Unlock()
+	if entry == nil || !j.matches(ctx, path, entry) {
+		return nil
+	}
+	return entry
+}
+
+// matches 判断文件是否与记录一致：大小和修改时间相同，或大小相同且哈希相同
+func (j *Journal) matches(ctx context.Context, path string, e *JournalEntry) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() != e.Size {
+		return false
+	}
+	if info.ModTime().UnixNano() == e.ModTime {
+		return true
+	}
+	if e.Hash == "" {
+		return false
+	}
+	sums, err := calculateFileHashes(ctx, path, []string{j.Algorithm})
+	return err == nil && sums[j.Algorithm] == e.Hash
+}
+
+// record 记录任务结果，满足刷新条件时写出断点文件
+func (j *Journal) record(res Result) error {
+	rel, err := manifestRelPath(j.SrcRoot, absPath(res.OldName))
+	if err != nil {
+		return err
+	}
+
+	entry := &JournalEntry{Status: JournalDone, Hash: res.SrcHashes[j.Algorithm]}
+	if res.Err != nil {
+		entry.Status = JournalFailed
+		entry.Error = res.Err.Error()
+	}
+	if res.NewName != "" {
+		entry.NewName = absPath(res.NewName)
+	}
+	// 移动/重命名成功后源文件已不存在，使用输出文件的信息（大小和修改时间不变）
+	info, statErr := os.Stat(res.OldName)
+	if statErr != nil && res.NewName != "" {
+		info, statErr = os.Stat(res.NewName)
+	}
+	if statErr == nil {
+		entry.Size = info.Size()
+		entry.ModTime = info.ModTime().UnixNano()
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.Entries[rel] = entry
+	j.dirty++
+
+	since := time.Since(j.lastFlush)
+	if (j.dirty >= journalFlushTasks || since >= journalFlushInterval) && since >= j.flushCost*journalFlushCostRate {
+		return j.flushLocked()
+	}
+	return nil
+}
+
+// Flush 立即写出断点文件
+func (j *Journal) Flush() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.flushLocked()
+}
+
+// flushLocked 原子写出断点文件（调用方持有j.mu）
+func (j *Journal) flushLocked() error {
+	start := time.Now()
+	if err := os.MkdirAll(filepath.Dir(j.path), 0755); err != nil {
+		return err
+	}
+	err := writeFileAtomic(j.path, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(j)
+	})
+	if err != nil {
+		return fmt.Errorf("写出断点文件失败: %w", err)
+	}
+	j.dirty = 0
+	j.lastFlush = time.Now()
+	j.flushCost = j.lastFlush.Sub(start)
+	return nil
+}
+
+// absPath 返回绝对路径，失败时原样返回
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
@@ -0,0 +1,293 @@
+package fileutil
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ManifestEntry 校验清单中的一行：摘要 + 相对路径（使用/分隔）
+type ManifestEntry struct {
+	Sum  string
+	Path string
+}
+
+// manifestNames 各算法对应的coreutils惯用清单文件名
+var manifestNames = map[string]string{
+	HashMD5:     "MD5SUMS",
+	HashSHA1:    "SHA1SUMS",
+	HashSHA256:  "SHA256SUMS",
+	HashSHA512:  "SHA512SUMS",
+	HashBLAKE2b: "B2SUMS",
+	HashCRC32C:  "CRC32CSUMS",
+	HashXXHash:  "XXHSUMS",
+}
+
+// DefaultManifestName 返回算法对应的默认清单文件名，例如MD5SUMS
+func DefaultManifestName(algo string) string {
+	if name, ok := manifestNames[algo]; ok {
+		return name
+	}
+	return strings.ToUpper(algo) + "SUMS"
+}
+
+// DetectManifestAlgorithm 根据清单文件名或摘要长度推断哈希算法
+// 摘要长度为128时无法区分SHA512和BLAKE2b，除非文件名是B2SUMS，否则按SHA512处理
+func DetectManifestAlgorithm(manifestPath string, entries []ManifestEntry) (string, error) {
+	base := strings.ToUpper(filepath.Base(manifestPath))
+	for algo, name := range manifestNames {
+		if base == name {
+			return algo, nil
+		}
+	}
+	if len(entries) == 0 {
+		return DefaultAlgorithm, nil
+	}
+	switch len(entries[0].Sum) {
+	case 32:
+		return HashMD5, nil
+	case 40:
+		return HashSHA1, nil
+	case 64:
+		return HashSHA256, nil
+	case 128:
+		return HashSHA512, nil
+	case 8:
+		return HashCRC32C, nil
+	case 16:
+		return HashXXHash, nil
+	}
+	return "", fmt.Errorf("无法根据摘要长度推断哈希算法，请显式指定")
+}
+
+// manifestRelPath 计算清单中使用的相对路径
+func manifestRelPath(root, path string) (string, error) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// WriteManifest 以GNU coreutils md5sum/sha256sum格式写出清单（按路径排序）
+// 路径含反斜杠或换行时按coreutils约定在行首加\并转义
+func WriteManifest(w io.Writer, entries []ManifestEntry) error {
+	sorted := append([]ManifestEntry(nil), entries...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Path < sorted[j].Path })
+
+	bw := bufio.NewWriter(w)
+	for _, e := range sorted {
+		name := e.Path
+		prefix := ""
+		if strings.ContainsAny(name, "\\\n\r") {
+			prefix = "\\"
+			name = strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\r", "\\r").Replace(name)
+		}
+		if _, err := fmt.Fprintf(bw, "%s%s  %s\n", prefix, e.Sum, name); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// WriteManifestFile 原子写出清单文件（先写临时文件再重命名）
+func WriteManifestFile(path string, entries []ManifestEntry) error {
+	return writeFileAtomic(path, func(w io.Writer) error {
+		return WriteManifest(w, entries)
+	})
+}
+
+// ReadManifest 解析md5sum/sha256sum格式的清单，兼容二进制模式标记（*）和转义行
+func ReadManifest(r io.Reader) ([]ManifestEntry, error) {
+	var entries []ManifestEntry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		escaped := strings.HasPrefix(line, "\\")
+		if escaped {
+			line = line[1:]
+		}
+
+		sum, name, ok := strings.Cut(line, " ")
+		if !ok || sum == "" || len(name) < 2 || (name[0] != ' ' && name[0] != '*') {
+			return nil, fmt.Errorf("清单第%d行格式错误", lineNo)
+		}
+		name = name[1:]
+		if escaped {
+			name = unescapeManifestName(name)
+		}
+		entries = append(entries, ManifestEntry{Sum: strings.ToLower(sum), Path: name})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ReadManifestFile 读取清单文件
+func ReadManifestFile(path string) ([]ManifestEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadManifest(f)
+}
+
+// unescapeManifestName 还原coreutils转义的文件名
+func unescapeManifestName(name string) string {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] == '\\' && i+1 < len(name) {
+			i++
+			switch name[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			default:
+				b.WriteByte(name[i])
+			}
+			continue
+		}
+		b.WriteByte(name[i])
+	}
+	return b.String()
+}
+
+// VerifyStatus 清单校验状态
+type VerifyStatus string
+
+const (
+	VerifyOK      VerifyStatus = "OK"      // 摘要一致
+	VerifyFailed  VerifyStatus = "FAILED"  // 摘要不一致或无法读取
+	VerifyMissing VerifyStatus = "MISSING" // 清单中有、目录中没有
+	VerifyNew     VerifyStatus = "NEW"     // 目录中有、清单中没有
+)
+
+// VerifyEntry 单个文件的校验结果
+type VerifyEntry struct {
+	Path     string       // 相对路径
+	Status   VerifyStatus // 校验状态
+	Expected string       // 清单中的摘要
+	Actual   string       // 实际摘要
+	Err      error        // 读取错误
+}
+
+// VerifyOptions 清单校验参数
+type VerifyOptions struct {
+	ManifestPath string // 清单文件路径
+	Root         string // 清单中相对路径的根目录（为空时使用清单所在目录）
+	Algorithm    string // 哈希算法（为空时自动推断）
+	Workers      int    // 并发Worker数
+}
+
+// VerifyReport 清单校验报告
+type VerifyReport struct {
+	Algorithm string        // 使用的哈希算法
+	Entries   []VerifyEntry // 按路径排序的校验结果
+	Counts    map[VerifyStatus]int
+}
+
+// VerifyManifest 使用Worker Pool并行重新计算目录下所有文件的哈希，并与清单比对
+func VerifyManifest(ctx context.Context, opts VerifyOptions) (*VerifyReport, error) {
+	entries, err := ReadManifestFile(opts.ManifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取清单失败: %w", err)
+	}
+
+	algo := opts.Algorithm
+	if algo == "" {
+		if algo, err = DetectManifestAlgorithm(opts.ManifestPath, entries); err != nil {
+			return nil, err
+		}
+	}
+	root := opts.Root
+	if root == "" {
+		root = filepath.Dir(opts.ManifestPath)
+	}
+
+	expected := make(map[string]string, len(entries))
+	for _, e := range entries {
+		expected[e.Path] = e.Sum
+	}
+
+	// 并行计算目录下所有文件的哈希
+	actual := map[string]Result{}
+	scheduler := NewScheduler(SchedulerConfig{
+		SrcRoot:      root,
+		Mode:         "md5",
+		Workers:      opts.Workers,
+		Algorithms:   []string{algo},
+		ExcludePaths: []string{opts.ManifestPath},
+		ErrorHandler: newSkipAllHandler(),
+	})
+	results, err := scheduler.Start(ctx)
+	if err != nil && len(entries) == 0 {
+		return nil, err
+	}
+	if err == nil {
+		for res := range results {
+			rel, relErr := manifestRelPath(root, res.OldName)
+			if relErr != nil {
+				continue
+			}
+			actual[rel] = res
+		}
+		if summary := scheduler.Wait(); summary.Interrupted {
+			return nil, ctx.Err()
+		}
+	}
+
+	report := &VerifyReport{Algorithm: algo, Counts: map[VerifyStatus]int{}}
+	for path, sum := range expected {
+		entry := VerifyEntry{Path: path, Expected: sum}
+		res, ok := actual[path]
+		switch {
+		case !ok:
+			entry.Status = VerifyMissing
+		case res.Err != nil:
+			entry.Status = VerifyFailed
+			entry.Err = res.Err
+		case res.SrcHashes[algo] == sum:
+			entry.Status = VerifyOK
+			entry.Actual = res.SrcHashes[algo]
+		default:
+			entry.Status = VerifyFailed
+			entry.Actual = res.SrcHashes[algo]
+		}
+		report.Entries = append(report.Entries, entry)
+	}
+	for path, res := range actual {
+		if _, ok := expected[path]; !ok {
+			report.Entries = append(report.Entries, VerifyEntry{Path: path, Status: VerifyNew, Actual: res.SrcHashes[algo]})
+		}
+	}
+
+	sort.Slice(report.Entries, func(i, j int) bool { return report.Entries[i].Path < report.Entries[j].Path })
+	for _, e := range report.Entries {
+		report.Counts[e.Status]++
+	}
+	return report, nil
+}
+
+// newSkipAllHandler 创建对所有错误都跳过、不重试的错误处理器（只读校验场景使用）
+func newSkipAllHandler() *ErrorHandler {
+	h := NewErrorHandler()
+	for et := range h.policies {
+		h.SetPolicy(et, PolicySkip)
+	}
+	return h
+}
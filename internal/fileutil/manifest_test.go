@@ -0,0 +1,180 @@
+package fileutil
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestManifestRoundTrip 写出的清单读回后路径不变，含反斜杠和换行的路径按coreutils约定转义
+func TestManifestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		escaped bool
+	}{
+		{"普通路径", "dir/file.txt", false},
+		{"含空格", "dir/my file.txt", false},
+		{"以星号开头", "*star.txt", false},
+		{"含反斜杠", `back\slash.txt`, true},
+		{"含换行", "line\nbreak.txt", true},
+		{"含回车", "carriage\rreturn.txt", true},
+		{"反斜杠后跟n", `literal\n.txt`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := []ManifestEntry{{Sum: "d41d8cd98f00b204e9800998ecf8427e", Path: tt.path}}
+			var buf bytes.Buffer
+			if err := WriteManifest(&buf, entries); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.HasPrefix(buf.String(), `\`); got != tt.escaped {
+				t.Errorf("转义标记 = %v, 期望 %v（%q）", got, tt.escaped, buf.String())
+			}
+			if strings.Count(buf.String(), "\n") != 1 {
+				t.Errorf("清单应只有一行: %q", buf.String())
+			}
+			got, err := ReadManifest(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, entries) {
+				t.Errorf("读回 %q, 期望 %q", got, entries)
+			}
+		})
+	}
+}
+
+// TestReadManifest 兼容二进制模式标记、注释、空行、CRLF和大写摘要，拒绝格式错误的行
+func TestReadManifest(t *testing.T) {
+	input := "# comment\r\n" +
+		"D41D8CD98F00B204E9800998ECF8427E  text.txt\r\n" +
+		"\n" +
+		"0cc175b9c0f1b6a831c399e269772661 *binary.bin\n"
+	got, err := ReadManifest(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ManifestEntry{
+		{Sum: "d41d8cd98f00b204e9800998ecf8427e", Path: "text.txt"},
+		{Sum: "0cc175b9c0f1b6a831c399e269772661", Path: "binary.bin"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadManifest = %q, 期望 %q", got, want)
+	}
+
+	for _, line := range []string{"nosum", "abc file.txt", "abc  "} {
+		if _, err := ReadManifest(strings.NewReader(line + "\n")); err == nil {
+			t.Errorf("ReadManifest(%q) 应返回格式错误", line)
+		}
+	}
+}
+
+// TestDetectManifestAlgorithm 优先按coreutils惯用文件名推断，否则按摘要长度推断
+func TestDetectManifestAlgorithm(t *testing.T) {
+	sum := func(n int) []ManifestEntry {
+		return []ManifestEntry{{Sum: strings.Repeat("a", n), Path: "f"}}
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		entries []ManifestEntry
+		want    string
+		wantErr bool
+	}{
+		{"MD5SUMS", "dir/MD5SUMS", sum(64), HashMD5, false},
+		{"小写文件名", "dir/sha256sums", sum(32), HashSHA256, false},
+		{"B2SUMS", "B2SUMS", sum(128), HashBLAKE2b, false},
+		{"长度32", "list.txt", sum(32), HashMD5, false},
+		{"长度40", "list.txt", sum(40), HashSHA1, false},
+		{"长度64", "list.txt", sum(64), HashSHA256, false},
+		{"长度128按SHA512", "list.txt", sum(128), HashSHA512, false},
+		{"长度8", "list.txt", sum(8), HashCRC32C, false},
+		{"长度16", "list.txt", sum(16), HashXXHash, false},
+		{"空清单", "list.txt", nil, DefaultAlgorithm, false},
+		{"无法推断", "list.txt", sum(20), "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectManifestAlgorithm(tt.path, tt.entries)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DetectManifestAlgorithm 错误 = %v, 期望出错 %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("DetectManifestAlgorithm = %s, 期望 %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestVerifyManifest 清单校验区分一致、内容被修改、文件缺失和清单外的新文件
+func TestVerifyManifest(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("same.txt", "same")
+	write("sub/modified.txt", "before")
+	write("missing.txt", "gone soon")
+
+	ctx := context.Background()
+	var entries []ManifestEntry
+	for _, name := range []string{"same.txt", "sub/modified.txt", "missing.txt"} {
+		sums, err := calculateFileHashes(ctx, filepath.Join(dir, filepath.FromSlash(name)), []string{HashSHA256})
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, ManifestEntry{Sum: sums[HashSHA256], Path: name})
+	}
+	manifest := filepath.Join(dir, "SHA256SUMS")
+	if err := WriteManifestFile(manifest, entries); err != nil {
+		t.Fatal(err)
+	}
+
+	write("sub/modified.txt", "after")
+	write("new.txt", "new")
+	if err := os.Remove(filepath.Join(dir, "missing.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := VerifyManifest(ctx, VerifyOptions{ManifestPath: manifest, Workers: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Algorithm != HashSHA256 {
+		t.Errorf("Algorithm = %s, 期望 %s", report.Algorithm, HashSHA256)
+	}
+
+	got := map[string]VerifyStatus{}
+	for _, e := range report.Entries {
+		got[e.Path] = e.Status
+	}
+	want := map[string]VerifyStatus{
+		"same.txt":         VerifyOK,
+		"sub/modified.txt": VerifyFailed,
+		"missing.txt":      VerifyMissing,
+		"new.txt":          VerifyNew,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("校验结果 = %v, 期望 %v", got, want)
+	}
+	for status, n := range map[VerifyStatus]int{VerifyOK: 1, VerifyFailed: 1, VerifyMissing: 1, VerifyNew: 1} {
+		if report.Counts[status] != n {
+			t.Errorf("Counts[%s] = %d, 期望 %d", status, report.Counts[status], n)
+		}
+	}
+}
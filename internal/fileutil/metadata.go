@@ -0,0 +1,206 @@
+package fileutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// MetaAttr 复制文件时可以保留的元数据
+type MetaAttr string
+
+const (
+	MetaTimes  MetaAttr = "times"  // 修改时间和访问时间
+	MetaMode   MetaAttr = "mode"   // 权限位
+	MetaOwner  MetaAttr = "owner"  // 属主和属组（非特权用户通常只能保留为自己的文件）
+	MetaXattrs MetaAttr = "xattrs" // 扩展属性（如SELinux标签、用户标签）
+	MetaACLs   MetaAttr = "acls"   // 访问控制列表
+)
+
+// metaAttrLabels 元数据的显示名称
+var metaAttrLabels = map[MetaAttr]string{
+	MetaTimes:  "时间",
+	MetaMode:   "权限",
+	MetaOwner:  "属主",
+	MetaXattrs: "扩展属性",
+	MetaACLs:   "ACL",
+}
+
+// MetaAttrs 返回所有可保留的元数据
+func MetaAttrs() []MetaAttr {
+	return []MetaAttr{MetaTimes, MetaMode, MetaOwner, MetaXattrs, MetaACLs}
+}
+
+// Label 返回元数据的显示名称
+func (a MetaAttr) Label() string {
+	if label, ok := metaAttrLabels[a]; ok {
+		return label
+	}
+	return string(a)
+}
+
+// errMetaUnsupported 当前平台不支持保留该元数据
+var errMetaUnsupported = errors.New("当前平台不支持")
+
+// Preserve 复制文件时保留的元数据集合（为nil时使用DefaultPreserve，空集合表示都不保留）
+type Preserve []MetaAttr
+
+// DefaultPreserve 默认保留权限和时间
+var DefaultPreserve = Preserve{MetaMode, MetaTimes}
+
+// ParsePreserve 解析要保留的元数据（如 times,mode,owner）：all表示当前平台支持的全部，none表示都不保留
+func ParsePreserve(specs []string) (Preserve, error) {
+	p := Preserve{}
+	add := func(a MetaAttr) {
+		if !p.Has(a) {
+			p = append(p, a)
+		}
+	}
+	for _, spec := range specs {
+		for _, name := range strings.Split(spec, ",") {
+			switch name = strings.ToLower(strings.TrimSpace(name)); name {
+			case "", "none":
+			case "all":
+				for _, a := range SupportedPreserve() {
+					add(a)
+				}
+			default:
+				a := MetaAttr(name)
+				if _, ok := metaAttrLabels[a]; !ok {
+					return nil, fmt.Errorf("不支持的元数据: %s（可选：%s/all/none）", name, strings.Join(Preserve(MetaAttrs()).Names(), "/"))
+				}
+				add(a)
+			}
+		}
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Validate 检查元数据集合中的每一项在当前平台上都能保留
+func (p Preserve) Validate() error {
+	for _, a := range p {
+		if _, ok := metaAttrLabels[a]; !ok {
+			return fmt.Errorf("不支持的元数据: %s", a)
+		}
+		if !supportsMeta(a) {
+			return fmt.Errorf("当前平台不支持保留%s", a.Label())
+		}
+	}
+	return nil
+}
+
+// Has 判断是否保留指定的元数据
+func (p Preserve) Has(a MetaAttr) bool {
+	return slices.Contains(p, a)
+}
+
+// Names 返回元数据集合中各项的名称（与ParsePreserve对应）
+func (p Preserve) Names() []string {
+	names := []string{}
+	for _, a := range p {
+		names = append(names, string(a))
+	}
+	return names
+}
+
+// String 返回元数据集合的显示名称
+func (p Preserve) String() string {
+	if len(p) == 0 {
+		return "无"
+	}
+	var labels []string
+	for _, a := range p {
+		labels = append(labels, a.Label())
+	}
+	return strings.Join(labels, "、")
+}
+
+// SupportedPreserve 返回当前平台支持保留的全部元数据
+func SupportedPreserve() Preserve {
+	var p Preserve
+	for _, a := range MetaAttrs() {
+		if supportsMeta(a) {
+			p = append(p, a)
+		}
+	}
+	return p
+}
+
+// orDefault 为nil时返回默认集合
+func (p Preserve) orDefault() Preserve {
+	if p == nil {
+		return DefaultPreserve
+	}
+	return p
+}
+
+// MetaFailure 未能保留的元数据及原因（文件内容已复制成功）
+type MetaFailure struct {
+	Attr MetaAttr
+	Err  error
+}
+
+// FormatMetaFailures 格式化单个文件未能保留的元数据
+func FormatMetaFailures(failures []MetaFailure) string {
+	var parts []string
+	for _, f := range failures {
+		parts = append(parts, fmt.Sprintf("%s(%v)", f.Attr.Label(), f.Err))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// FormatMetaCounts 格式化各元数据未能保留的文件数
+func FormatMetaCounts(counts map[MetaAttr]int) string {
+	var parts []string
+	for _, a := range MetaAttrs() {
+		if n := counts[a]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", a.Label(), n))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// preserveMetadata 把源文件的属主、扩展属性、ACL和权限应用到已写完内容的目标文件，
+// 返回未能保留的元数据（时间在目标文件不再读写之后由preserveTimes设置）
+func preserveMetadata(src, dst *os.File, srcInfo os.FileInfo, preserve Preserve) []MetaFailure {
+	var failures []MetaFailure
+	fail := func(a MetaAttr, err error) {
+		if err != nil {
+			failures = append(failures, MetaFailure{Attr: a, Err: err})
+		}
+	}
+
+	// 修改属主会清除setuid/setgid位，需在设置权限之前
+	if preserve.Has(MetaOwner) {
+		fail(MetaOwner, copyOwner(src, dst))
+	}
+	if preserve.Has(MetaXattrs) || preserve.Has(MetaACLs) {
+		xattrErr, aclErr := copyXattrs(src, dst, preserve.Has(MetaXattrs), preserve.Has(MetaACLs))
+		fail(MetaXattrs, xattrErr)
+		fail(MetaACLs, aclErr)
+	}
+	// 临时文件创建时只有属主可读写，不保留权限时使用普通文件的默认权限
+	mode := os.FileMode(0644)
+	if preserve.Has(MetaMode) {
+		mode = srcInfo.Mode()
+	}
+	fail(MetaMode, dst.Chmod(mode))
+	return failures
+}
+
+// preserveTimes 把源文件的访问时间和修改时间应用到目标路径（atime为零值时不修改访问时间）
+func preserveTimes(path string, atime, mtime time.Time, preserve Preserve) []MetaFailure {
+	if !preserve.Has(MetaTimes) {
+		return nil
+	}
+	if err := os.Chtimes(path, atime, mtime); err != nil {
+		return []MetaFailure{{Attr: MetaTimes, Err: err}}
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+//go:build !(linux || darwin || freebsd || windows)
+
+package fileutil
+
+import (
+	"os"
+	"time"
+)
+
+// supportsMeta 判断当前平台能否保留指定的元数据（只支持时间和权限）
+func supportsMeta(a MetaAttr) bool {
+	return a == MetaTimes || a == MetaMode
+}
+
+// accessTime 当前平台无法获取访问时间，只保留修改时间
+func accessTime(*os.File) time.Time {
+	return time.Time{}
+}
+
+// copyOwner 当前平台不支持保留属主
+func copyOwner(_, _ *os.File) error {
+	return errMetaUnsupported
+}
+
+// copyXattrs 当前平台不支持保留扩展属性和ACL
+func copyXattrs(_, _ *os.File, _, _ bool) (error, error) {
+	return errMetaUnsupported, errMetaUnsupported
+}
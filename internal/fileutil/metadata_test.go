@@ -0,0 +1,192 @@
+//go:build linux
+
+package fileutil
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// TestParsePreserve 逗号分隔和多个参数等价，忽略大小写、空白和重复项；none得到非nil的空集合
+func TestParsePreserve(t *testing.T) {
+	tests := []struct {
+		name    string
+		specs   []string
+		want    Preserve
+		wantErr string
+	}{
+		{"逗号分隔", []string{"times,mode"}, Preserve{MetaTimes, MetaMode}, ""},
+		{"多个参数", []string{"mode", "owner"}, Preserve{MetaMode, MetaOwner}, ""},
+		{"大小写和空白", []string{" Mode , TIMES "}, Preserve{MetaMode, MetaTimes}, ""},
+		{"重复项", []string{"mode,mode", "times,mode"}, Preserve{MetaMode, MetaTimes}, ""},
+		{"全部", []string{"all"}, SupportedPreserve(), ""},
+		{"全部后重复", []string{"xattrs,all"}, Preserve{MetaXattrs, MetaTimes, MetaMode, MetaOwner, MetaACLs}, ""},
+		{"都不保留", []string{"none"}, Preserve{}, ""},
+		{"空参数", nil, Preserve{}, ""},
+		{"不支持的元数据", []string{"mode,color"}, nil, "不支持的元数据: color"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePreserve(tt.specs)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParsePreserve(%q) 错误 = %v, 期望包含 %q", tt.specs, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePreserve(%q) 返回错误: %v", tt.specs, err)
+			}
+			if got == nil || !slices.Equal(got, tt.want) {
+				t.Errorf("ParsePreserve(%q) = %#v, 期望 %#v", tt.specs, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPreserve 集合的查询、名称和显示；nil表示默认集合，空集合表示都不保留
+func TestPreserve(t *testing.T) {
+	tests := []struct {
+		name    string
+		p       Preserve
+		names   []string
+		str     string
+		times   bool
+		wantErr string
+	}{
+		{"默认", DefaultPreserve, []string{"mode", "times"}, "权限、时间", true, ""},
+		{"空集合", Preserve{}, []string{}, "无", false, ""},
+		{"nil", nil, []string{}, "无", false, ""},
+		{"全部", Preserve(MetaAttrs()), []string{"times", "mode", "owner", "xattrs", "acls"}, "时间、权限、属主、扩展属性、ACL", true, ""},
+		{"不支持的元数据", Preserve{MetaMode, "color"}, []string{"mode", "color"}, "权限、color", false, "不支持的元数据: color"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.p.Names(); !slices.Equal(got, tt.names) {
+				t.Errorf("Names() = %q, 期望 %q", got, tt.names)
+			}
+			if got := tt.p.String(); got != tt.str {
+				t.Errorf("String() = %q, 期望 %q", got, tt.str)
+			}
+			if got := tt.p.Has(MetaTimes); got != tt.times {
+				t.Errorf("Has(times) = %v, 期望 %v", got, tt.times)
+			}
+			err := tt.p.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() 返回错误: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() 错误 = %v, 期望包含 %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if got := Preserve(nil).orDefault(); !slices.Equal(got, DefaultPreserve) {
+		t.Errorf("nil.orDefault() = %v, 期望 %v", got, DefaultPreserve)
+	}
+	if got := (Preserve{}).orDefault(); got == nil || len(got) != 0 {
+		t.Errorf("空集合.orDefault() = %#v, 期望空集合", got)
+	}
+}
+
+// TestFormatMeta 未能保留的元数据按出现顺序列出原因，统计按MetaAttrs的顺序列出非零项
+func TestFormatMeta(t *testing.T) {
+	failures := []MetaFailure{
+		{Attr: MetaOwner, Err: errors.New("operation not permitted")},
+		{Attr: MetaTimes, Err: errors.New("x")},
+	}
+	if got, want := FormatMetaFailures(failures), "属主(operation not permitted); 时间(x)"; got != want {
+		t.Errorf("FormatMetaFailures() = %q, 期望 %q", got, want)
+	}
+	if got := FormatMetaFailures(nil); got != "" {
+		t.Errorf("FormatMetaFailures(nil) = %q, 期望为空", got)
+	}
+
+	counts := map[MetaAttr]int{MetaACLs: 1, MetaTimes: 2, MetaMode: 0}
+	if got, want := FormatMetaCounts(counts), "时间 2, ACL 1"; got != want {
+		t.Errorf("FormatMetaCounts() = %q, 期望 %q", got, want)
+	}
+}
+
+// testXattr 测试使用的用户扩展属性
+const testXattr = "user.fileutil.test"
+
+// TestCopyFilePreserve 复制时只保留指定的元数据；不保留权限时使用0644，不保留时间时修改时间为复制时刻
+func TestCopyFilePreserve(t *testing.T) {
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.Local)
+	tests := []struct {
+		name     string
+		preserve Preserve
+		mode     os.FileMode
+		times    bool
+		xattr    bool
+	}{
+		{"默认", nil, 0640, true, false},
+		{"都不保留", Preserve{}, 0644, false, false},
+		{"只保留时间", Preserve{MetaTimes}, 0644, true, false},
+		{"只保留权限", Preserve{MetaMode}, 0640, false, false},
+		{"时间、权限和扩展属性", Preserve{MetaTimes, MetaMode, MetaXattrs}, 0640, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			src := filepath.Join(dir, "src")
+			dst := filepath.Join(dir, "dst")
+			if err := os.WriteFile(src, []byte("content"), 0640); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chmod(src, 0640); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chtimes(src, mtime, mtime); err != nil {
+				t.Fatal(err)
+			}
+			if err := unix.Setxattr(src, testXattr, []byte("value"), 0); err != nil {
+				if tt.xattr {
+					t.Skipf("文件系统不支持用户扩展属性: %v", err)
+				}
+			}
+
+			metaErrs, err := CopyFile(context.Background(), src, dst, tt.preserve)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(metaErrs) != 0 {
+				t.Errorf("未能保留的元数据: %s", FormatMetaFailures(metaErrs))
+			}
+
+			info, err := os.Stat(dst)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != tt.mode {
+				t.Errorf("权限 = %v, 期望 %v", info.Mode().Perm(), tt.mode)
+			}
+			if got := info.ModTime().Equal(mtime); got != tt.times {
+				t.Errorf("修改时间 = %v, 期望保留源文件时间: %v", info.ModTime(), tt.times)
+			}
+			value := make([]byte, 64)
+			n, err := unix.Getxattr(dst, testXattr, value)
+			if tt.xattr && (err != nil || string(value[:n]) != "value") {
+				t.Errorf("扩展属性 = %q, %v, 期望 %q", value[:max(n, 0)], err, "value")
+			}
+			if !tt.xattr && err == nil {
+				t.Errorf("不保留扩展属性时目标文件仍有 %s", testXattr)
+			}
+		})
+	}
+}
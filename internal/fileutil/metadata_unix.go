@@ -0,0 +1,125 @@
+//go:build linux || darwin || freebsd
+
+package fileutil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// aclXattrPrefix Linux把POSIX ACL保存在以此为前缀的扩展属性中
+const aclXattrPrefix = "system.posix_acl_"
+
+// supportsMeta 判断当前平台能否保留指定的元数据（ACL只支持Linux）
+func supportsMeta(a MetaAttr) bool {
+	return a != MetaACLs || runtime.GOOS == "linux"
+}
+
+// accessTime 返回打开的文件的访问时间（应在读取文件内容之前获取）
+func accessTime(f *os.File) time.Time {
+	var st unix.Stat_t
+	if err := unix.Fstat(int(f.Fd()), &st); err != nil {
+		return time.Time{}
+	}
+	return time.Unix(st.Atim.Unix())
+}
+
+// copyOwner 把源文件的属主和属组应用到目标文件，两者已经相同时不修改
+func copyOwner(src, dst *os.File) error {
+	var srcSt, dstSt unix.Stat_t
+	if err := unix.Fstat(int(src.Fd()), &srcSt); err != nil {
+		return err
+	}
+	if err := unix.Fstat(int(dst.Fd()), &dstSt); err != nil {
+		return err
+	}
+	if srcSt.Uid == dstSt.Uid && srcSt.Gid == dstSt.Gid {
+		return nil
+	}
+	return dst.Chown(int(srcSt.Uid), int(srcSt.Gid))
+}
+
+// copyXattrs 把源文件的扩展属性复制到目标文件，xattrs和acls分别控制普通扩展属性和ACL，
+// 分别返回两者遇到的第一个错误（个别属性失败时继续复制其余属性）
+func copyXattrs(src, dst *os.File, xattrs, acls bool) (xattrErr, aclErr error) {
+	names, err := listXattrs(src)
+	if err != nil {
+		if errors.Is(err, unix.ENOTSUP) {
+			// 源文件系统不支持扩展属性，没有需要复制的内容
+			return nil, nil
+		}
+		return err, err
+	}
+
+	for _, name := range names {
+		isACL := strings.HasPrefix(name, aclXattrPrefix)
+		if isACL && !acls || !isACL && !xattrs {
+			continue
+		}
+		err := copyXattr(src, dst, name)
+		switch {
+		case err == nil:
+		case isACL && aclErr == nil:
+			aclErr = err
+		case !isACL && xattrErr == nil:
+			xattrErr = err
+		}
+	}
+	return xattrErr, aclErr
+}
+
+// listXattrs 返回文件的所有扩展属性名
+func listXattrs(f *os.File) ([]string, error) {
+	fd := int(f.Fd())
+	for {
+		size, err := unix.Flistxattr(fd, nil)
+		if err != nil || size == 0 {
+			return nil, err
+		}
+		buf := make([]byte, size)
+		n, err := unix.Flistxattr(fd, buf)
+		if errors.Is(err, unix.ERANGE) {
+			// 两次调用之间属性有变化，重新获取
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, name := range bytes.Split(buf[:n], []byte{0}) {
+			if len(name) > 0 {
+				names = append(names, string(name))
+			}
+		}
+		return names, nil
+	}
+}
+
+// copyXattr 把源文件的一个扩展属性复制到目标文件
+func copyXattr(src, dst *os.File, name string) error {
+	for {
+		size, err := unix.Fgetxattr(int(src.Fd()), name, nil)
+		if err != nil {
+			return fmt.Errorf("读取%s失败: %w", name, err)
+		}
+		value := make([]byte, size)
+		n, err := unix.Fgetxattr(int(src.Fd()), name, value)
+		if errors.Is(err, unix.ERANGE) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("读取%s失败: %w", name, err)
+		}
+		if err := unix.Fsetxattr(int(dst.Fd()), name, value[:n], 0); err != nil {
+			return fmt.Errorf("设置%s失败: %w", name, err)
+		}
+		return nil
+	}
+}
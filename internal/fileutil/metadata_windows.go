@@ -0,0 +1,36 @@
+//go:build windows
+
+package fileutil
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// supportsMeta 判断当前平台能否保留指定的元数据（只支持时间和权限）
+func supportsMeta(a MetaAttr) bool {
+	return a == MetaTimes || a == MetaMode
+}
+
+// accessTime 返回打开的文件的访问时间（应在读取文件内容之前获取）
+func accessTime(f *os.File) time.Time {
+	info, err := f.Stat()
+	if err != nil {
+		return time.Time{}
+	}
+	if data, ok := info.Sys().(*syscall.Win32FileAttributeData); ok {
+		return time.Unix(0, data.LastAccessTime.Nanoseconds())
+	}
+	return time.Time{}
+}
+
+// copyOwner 当前平台不支持保留属主
+func copyOwner(_, _ *os.File) error {
+	return errMetaUnsupported
+}
+
+// copyXattrs 当前平台不支持保留扩展属性和ACL
+func copyXattrs(_, _ *os.File, _, _ bool) (error, error) {
+	return errMetaUnsupported, errMetaUnsupported
+}
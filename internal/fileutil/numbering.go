@@ -0,0 +1,201 @@
+package fileutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 序号重命名时文件的排序方式
+const (
+	SortByName    = "name"    // 按相对路径的字典序
+	SortByNatural = "natural" // 按相对路径的自然顺序（file2 排在 file10 之前）
+	SortByMtime   = "mtime"   // 按修改时间（从早到晚）
+	SortBySize    = "size"    // 按文件大小（从小到大）
+)
+
+// sortModeLabels 排序方式的显示名称
+var sortModeLabels = map[string]string{
+	SortByName:    "文件名",
+	SortByNatural: "自然顺序",
+	SortByMtime:   "修改时间",
+	SortBySize:    "文件大小",
+}
+
+// SortModes 返回所有支持的排序方式
+func SortModes() []string {
+	return []string{SortByName, SortByNatural, SortByMtime, SortBySize}
+}
+
+// SortModeLabel 返回排序方式的显示名称
+func SortModeLabel(mode string) string {
+	if label, ok := sortModeLabels[mode]; ok {
+		return label
+	}
+	return mode
+}
+
+// maxSeqPad 序号补零的最大位数
+const maxSeqPad = 18
+
+// Numbering 序号设置：先按指定方式排序文件列表，再依次分配序号，与Worker完成顺序无关
+type Numbering struct {
+	Sort  string `json:"sort,omitempty"` // 排序方式（为空时按文件名）
+	Start int    `json:"start"`          // 起始序号
+	Step  int    `json:"step"`           // 步长
+	Pad   int    `json:"pad,omitempty"`  // 补零位数（为0时按最大序号的位数自动补零）
+}
+
+// DefaultNumbering 返回默认序号设置：按文件名排序，从1开始，步长1，自动补零
+func DefaultNumbering() Numbering {
+	return Numbering{Sort: SortByName, Start: 1, Step: 1}
+}
+
+// Validate 校验序号设置
+func (n Numbering) Validate() error {
+	switch n.Sort {
+	case "", SortByName, SortByNatural, SortByMtime, SortBySize:
+	default:
+		return fmt.Errorf("不支持的排序方式: %s（可选：%s）", n.Sort, strings.Join(SortModes(), "/"))
+	}
+	if n.Start < 0 {
+		return fmt.Errorf("起始序号不能为负数")
+	}
+	if n.Step < 1 {
+		return fmt.Errorf("序号步长必须大于0")
+	}
+	if n.Pad < 0 || n.Pad > maxSeqPad {
+		return fmt.Errorf("补零位数应在0到%d之间", maxSeqPad)
+	}
+	return nil
+}
+
+// sameNumbering 判断两个序号设置是否相同（都为空也视为相同）
+func sameNumbering(a, b *Numbering) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// sortFiles 按排序方式返回排序后的文件列表副本（相同时按相对路径排序，保证结果确定）
+func sortFiles(root string, files []string, mode string) []string {
+	type entry struct {
+		path string
+		rel  string
+		key  int64
+	}
+	entries := make([]entry, len(files))
+	for i, f := range files {
+		e := entry{path: f, rel: f}
+		if rel, err := filepath.Rel(root, f); err == nil && root != "" {
+			e.rel = filepath.ToSlash(rel)
+		}
+		if mode == SortByMtime || mode == SortBySize {
+			if info, err := os.Stat(f); err == nil {
+				if mode == SortByMtime {
+					e.key = info.ModTime().UnixNano()
+				} else {
+					e.key = info.Size()
+				}
+			}
+		}
+		entries[i] = e
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.key != b.key {
+			return a.key < b.key
+		}
+		if mode == SortByNatural && a.rel != b.rel {
+			return naturalLess(a.rel, b.rel)
+		}
+		return a.rel < b.rel
+	})
+
+	sorted := make([]string, len(entries))
+	for i, e := range entries {
+		sorted[i] = e.path
+	}
+	return sorted
+}
+
+// naturalLess 自然顺序比较：连续的数字按数值比较，其余部分按字典序比较
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		da, db := isDigit(a[0]), isDigit(b[0])
+		if da != db {
+			return a < b
+		}
+		var ca, cb string
+		ca, a = splitChunk(a, da)
+		cb, b = splitChunk(b, db)
+		if ca == cb {
+			continue
+		}
+		if da {
+			// 去掉前导零后位数少的数值小，位数相同时按字典序即数值大小
+			ta, tb := strings.TrimLeft(ca, "0"), strings.TrimLeft(cb, "0")
+			if len(ta) != len(tb) {
+				return len(ta) < len(tb)
+			}
+			if ta != tb {
+				return ta < tb
+			}
+			return len(ca) < len(cb)
+		}
+		return ca < cb
+	}
+	return len(a) < len(b)
+}
+
+// splitChunk 拆出开头连续的数字（digit为true）或非数字部分
+func splitChunk(s string, digit bool) (string, string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) == digit {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// isDigit 判断是否为ASCII数字
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// seqWidth 返回序号的补零位数：指定了位数时使用指定值，否则为最大序号的位数
+func seqWidth(n Numbering, count int) int {
+	if n.Pad > 0 {
+		return n.Pad
+	}
+	last := n.Start
+	if count > 0 {
+		last += (count - 1) * n.Step
+	}
+	return len(strconv.Itoa(last))
+}
+
+// formatSeq 按补零位数格式化序号
+func formatSeq(seq, width int) string {
+	return fmt.Sprintf("%0*d", width, seq)
+}
+
+// numberPath 按序号生成新的文件路径：前缀 + 补零后的序号 + 后缀 + 原扩展名
+func numberPath(t Task) (string, error) {
+	relPath := filepath.Base(t.Path)
+	if t.SrcRoot != "" {
+		var err error
+		if relPath, err = filepath.Rel(t.SrcRoot, t.Path); err != nil {
+			return "", err
+		}
+	}
+	filename := t.Prefix + formatSeq(t.Seq, t.SeqWidth) + t.Suffix + filepath.Ext(relPath)
+	if t.DestRoot != "" {
+		return filepath.Join(t.DestRoot, filepath.Dir(relPath), filename), nil
+	}
+	return filepath.Join(filepath.Dir(t.Path), filename), nil
+}
@@ -0,0 +1,162 @@
+package fileutil
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// maxAffixLength 前缀/后缀的最大字符数
+const maxAffixLength = 200
+
+// OperatorInfo 描述文件操作的元信息，供界面和命令行枚举
+type OperatorInfo struct {
+	Name        string // 模式代码（对应Task.Mode）
+	Label       string // 显示名称
+	NeedsDest   bool   // 是否需要目标目录
+	Renames     bool   // 是否应用重命名规则（前缀/后缀）
+	ReadOnly    bool   // 是否只读取文件、不做任何修改
+	Destructive bool   // 是否会改变源文件的位置（执行时写撤销日志）
+	Compares    bool   // 目标已存在时先比较再决定是否复制（生成计划时不视为冲突）
+}
+
+// Operator 文件操作统一接口，所有操作模式都通过该接口执行
+type Operator interface {
+	// Info 返回操作的元信息
+	Info() OperatorInfo
+	// Validate 在执行前校验任务参数
+	Validate(t Task) error
+	// Apply 执行操作，ctx取消时应尽快返回
+	Apply(ctx context.Context, t Task) Result
+}
+
+// Planner 可选接口：不修改文件系统，计算任务的输出路径（用于生成执行计划）
+type Planner interface {
+	// Target 返回任务的输出路径，不产生输出文件的操作返回空字符串
+	Target(ctx context.Context, t Task) (string, error)
+}
+
+var (
+	registryMu    sync.RWMutex
+	operators     []Operator
+	operatorIndex = map[string]Operator{}
+)
+
+// RegisterOperator 注册文件操作，名称不能与已注册的操作重复
+func RegisterOperator(op Operator) error {
+	info := op.Info()
+	if info.Name == "" {
+		return fmt.Errorf("操作名称不能为空")
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, exists := operatorIndex[info.Name]; exists {
+		return fmt.Errorf("操作已注册: %s", info.Name)
+	}
+	operatorIndex[info.Name] = op
+	operators = append(operators, op)
+	return nil
+}
+
+// LookupOperator 按名称查找已注册的文件操作
+func LookupOperator(name string) (Operator, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	op, ok := operatorIndex[name]
+	return op, ok
+}
+
+// Operators 按注册顺序返回所有文件操作
+func Operators() []Operator {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return append([]Operator(nil), operators...)
+}
+
+// builtinOperator 内置文件操作
+type builtinOperator struct {
+	info     OperatorInfo
+	apply    func(ctx context.Context, t Task) Result
+	validate func(t Task) error // 操作特有的参数校验（可为空）
+	naming   bool               // 输出路径是否应用重命名规则
+	output   bool               // 是否产生输出文件
+}
+
+func (o *builtinOperator) Info() OperatorInfo {
+	return o.info
+}
+
+func (o *builtinOperator) Validate(t Task) error {
+	if err := ValidateTask(o.info, t); err != nil {
+		return err
+	}
+	if o.validate != nil {
+		return o.validate(t)
+	}
+	return nil
+}
+
+func (o *builtinOperator) Apply(ctx context.Context, t Task) Result {
+	return o.apply(ctx, t)
+}
+
+func (o *builtinOperator) Target(ctx context.Context, t Task) (string, error) {
+	if !o.output {
+		return "", nil
+	}
+	return targetPath(ctx, t, o.naming)
+}
+
+// ValidateTask 按操作元信息校验任务的通用参数（目标目录、哈希算法、前缀/后缀、重命名模板、正则替换）
+func ValidateTask(info OperatorInfo, t Task) error {
+	if info.NeedsDest && t.DestRoot == "" {
+		return fmt.Errorf("%s操作需要指定目标目录", info.Label)
+	}
+	if err := ValidateAlgorithms(t.Algorithms); err != nil {
+		return err
+	}
+	if err := ValidateConflictPolicy(t.OnConflict); err != nil {
+		return err
+	}
+	if !info.Renames {
+		return nil
+	}
+	if t.Template != nil && (t.Prefix != "" || t.Suffix != "") {
+		return fmt.Errorf("重命名模板不能与前缀/后缀同时使用")
+	}
+	if t.Template != nil && t.Regex != nil {
+		return fmt.Errorf("重命名模板不能与正则替换同时使用")
+	}
+	for _, affix := range []struct{ name, value string }{{"前缀", t.Prefix}, {"后缀", t.Suffix}} {
+		if utf8.RuneCountInString(affix.value) > maxAffixLength {
+			return fmt.Errorf("%s长度不能超过%d个字符", affix.name, maxAffixLength)
+		}
+		if strings.ContainsAny(affix.value, `/\`) {
+			return fmt.Errorf("%s不能包含路径分隔符", affix.name)
+		}
+	}
+	return nil
+}
+
+func init() {
+	for _, op := range []*builtinOperator{
+		{info: OperatorInfo{Name: "md5", Label: "计算哈希", ReadOnly: true}, apply: processMD5},
+		{info: OperatorInfo{Name: "rename", Label: "重命名", Renames: true, Destructive: true}, apply: processRename, naming: true, output: true},
+		{info: OperatorInfo{Name: "copy", Label: "复制", NeedsDest: true}, apply: func(ctx context.Context, t Task) Result {
+			return processCopy(ctx, t, false)
+		}, output: true},
+		{info: OperatorInfo{Name: "copy_rename", Label: "复制+重命名", NeedsDest: true, Renames: true}, apply: func(ctx context.Context, t Task) Result {
+			return processCopy(ctx, t, true)
+		}, naming: true, output: true},
+		{info: OperatorInfo{Name: "move", Label: "移动", NeedsDest: true, Renames: true, Destructive: true}, apply: processMove, naming: true, output: true},
+		{info: OperatorInfo{Name: "sync", Label: "同步", NeedsDest: true, Compares: true}, apply: processSync, validate: validateSync, output: true},
+	} {
+		if err := RegisterOperator(op); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package fileutil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// planVersion 计划文件格式版本
+const planVersion = 1
+
+// Conflict 计划中检测到的冲突类型
+type Conflict string
+
+const (
+	ConflictExists    Conflict = "dest_exists"      // 目标路径已存在文件，执行时按冲突策略处理
+	ConflictDuplicate Conflict = "duplicate_target" // 多个源文件对应同一个目标路径
+	ConflictSamePath  Conflict = "same_path"        // 源路径与目标路径相同，不会有任何改变
+)
+
+// conflictLabels 冲突类型的显示名称
+var conflictLabels = map[Conflict]string{
+	ConflictExists:    "目标已存在",
+	ConflictDuplicate: "目标重复",
+	ConflictSamePath:  "源与目标相同",
+}
+
+// Label 返回冲突类型的显示名称
+func (c Conflict) Label() string {
+	if label, ok := conflictLabels[c]; ok {
+		return label
+	}
+	return string(c)
+}
+
+// PlanItem 计划中的单个文件
+type PlanItem struct {
+	Source    string     `json:"source"`              // 源文件路径
+	Target    string     `json:"target,omitempty"`    // 输出路径（只读操作为空）
+	Size      int64      `json:"size"`                // 源文件大小
+	Conflicts []Conflict `json:"conflicts,omitempty"` // 检测到的冲突
+}
+
+// Plan 批处理执行计划：列出每个源文件的输出路径和冲突，不修改文件系统
+type Plan struct {
+	Version    int                  `json:"version"`
+	Mode       string               `json:"mode"`
+	SrcRoot    string               `json:"src_root"`
+	DestRoot   string               `json:"dest_root,omitempty"`
+	Prefix     string               `json:"prefix,omitempty"`
+	Suffix     string               `json:"suffix,omitempty"`
+	Template   string               `json:"template,omitempty"`
+	Regex      *RegexRule           `json:"regex,omitempty"`
+	Transforms []string             `json:"transforms,omitempty"`
+	Numbering  *Numbering           `json:"numbering,omitempty"`
+	Filter     *Filter              `json:"filter,omitempty"`
+	Created    time.Time            `json:"created"`
+	Items      []PlanItem           `json:"items"`
+	TotalBytes int64                `json:"total_bytes"`         // 所有源文件的总大小
+	Conflicts  int                  `json:"conflicts"`           // 有冲突的文件数
+	Unmatched  int                  `json:"unmatched,omitempty"` // 不匹配正则规则、不会处理的文件数（不在Items中）
+	Filtered   map[FilterReason]int `json:"filtered,omitempty"`  // 扫描时各筛选条件排除的文件数（不在Items中）
+}
+
+// BuildPlan 扫描源目录，为每个文件计算输出路径并检测冲突，不修改文件系统
+func BuildPlan(ctx context.Context, cfg SchedulerConfig) (*Plan, error) {
+	op, ok := LookupOperator(cfg.Mode)
+	if !ok {
+		return nil, fmt.Errorf("不支持的操作模式: %s", cfg.Mode)
+	}
+	planner, ok := op.(Planner)
+	if !ok {
+		return nil, fmt.Errorf("%s操作不支持生成执行计划", op.Info().Label)
+	}
+	s := NewScheduler(cfg)
+	if err := s.prepare(); err != nil {
+		return nil, err
+	}
+	if err := op.Validate(s.newTask("")); err != nil {
+		return nil, err
+	}
+
+	exclude := map[string]bool{}
+	for _, p := range append([]string{cfg.ManifestPath}, cfg.ExcludePaths...) {
+		if p != "" {
+			exclude[absPath(p)] = true
+		}
+	}
+	files, _, err := scanFiles(cfg.SrcRoot, exclude, s.filter)
+	if err != nil {
+		return nil, fmt.Errorf("扫描源目录失败: %w", err)
+	}
+	s.numberFiles(files)
+
+	plan := &Plan{
+		Version:    planVersion,
+		Mode:       cfg.Mode,
+		SrcRoot:    cfg.SrcRoot,
+		DestRoot:   cfg.DestRoot,
+		Prefix:     cfg.Prefix,
+		Suffix:     cfg.Suffix,
+		Template:   cfg.Template,
+		Regex:      cfg.Regex,
+		Transforms: cfg.Transforms,
+		Numbering:  cfg.Numbering,
+		Filter:     cfg.Filter,
+		Created:    time.Now(),
+	}
+	if s.filter != nil {
+		plan.Filtered = s.filter.Counts()
+	}
+	targets := map[string][]int{}
+	sources := make(map[string]bool, len(files))
+	for _, f := range files {
+		sources[absPath(f)] = true
+	}
+	for _, f := range files {
+		item := PlanItem{Source: f}
+		if info, err := os.Stat(f); err == nil {
+			item.Size = info.Size()
+		}
+		target, err := planner.Target(ctx, s.newTask(f))
+		if errors.Is(err, ErrNoMatch) {
+			plan.Unmatched++
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("生成 %s 的目标路径失败: %w", f, err)
+		}
+		item.Target = target
+		plan.TotalBytes += item.Size
+		plan.Items = append(plan.Items, item)
+
+		if target != "" {
+			key := absPath(target)
+			targets[key] = append(targets[key], len(plan.Items)-1)
+		}
+	}
+
+	// 检测冲突（原地重命名时，目标路径上本批次的其他源文件会先被暂存，不视为已存在）
+	info := op.Info()
+	for key, idx := range targets {
+		for _, i := range idx {
+			item := &plan.Items[i]
+			switch {
+			case absPath(item.Source) == key:
+				item.Conflicts = append(item.Conflicts, ConflictSamePath)
+			case !info.Compares:
+				if _, err := os.Lstat(key); err == nil && !(stagesSwaps(info) && sources[key]) {
+					item.Conflicts = append(item.Conflicts, ConflictExists)
+				}
+			}
+			if len(idx) > 1 {
+				item.Conflicts = append(item.Conflicts, ConflictDuplicate)
+			}
+		}
+	}
+	for _, item := range plan.Items {
+		if len(item.Conflicts) > 0 {
+			plan.Conflicts++
+		}
+	}
+	return plan, nil
+}
+
+// Config 返回执行该计划所需的调度配置（模式、目录和规则取自计划，其余参数由调用方补充）
+func (p *Plan) Config() SchedulerConfig {
+	return SchedulerConfig{
+		SrcRoot:    p.SrcRoot,
+		DestRoot:   p.DestRoot,
+		Prefix:     p.Prefix,
+		Suffix:     p.Suffix,
+		Template:   p.Template,
+		Regex:      p.Regex,
+		Transforms: p.Transforms,
+		Numbering:  p.Numbering,
+		Filter:     p.Filter,
+		Mode:       p.Mode,
+		Plan:       p,
+	}
+}
+
+// Save 原子写出计划文件（JSON）。目录和文件路径保存为绝对路径，
+// 在其他工作目录下加载时仍对应相同的文件
+func (p *Plan) Save(path string) error {
+	saved, err := p.absolute()
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(saved)
+	})
+}
+
+// absolute 返回目录和文件路径都转换为绝对路径的计划副本
+func (p *Plan) absolute() (*Plan, error) {
+	abs := func(path string) (string, error) {
+		if path == "" {
+			return "", nil
+		}
+		return filepath.Abs(path)
+	}
+	saved := *p
+	var err error
+	if saved.SrcRoot, err = abs(p.SrcRoot); err != nil {
+		return nil, err
+	}
+	if saved.DestRoot, err = abs(p.DestRoot); err != nil {
+		return nil, err
+	}
+	saved.Items = make([]PlanItem, len(p.Items))
+	for i, item := range p.Items {
+		if item.Source, err = abs(item.Source); err != nil {
+			return nil, err
+		}
+		if item.Target, err = abs(item.Target); err != nil {
+			return nil, err
+		}
+		saved.Items[i] = item
+	}
+	return &saved, nil
+}
+
+// LoadPlan 读取计划文件
+func LoadPlan(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p := &Plan{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("解析计划文件失败: %w", err)
+	}
+	if p.Version != planVersion {
+		return nil, fmt.Errorf("不支持的计划文件版本: %d", p.Version)
+	}
+	if len(p.Items) == 0 {
+		return nil, fmt.Errorf("计划中没有文件")
+	}
+	return p, nil
+}
+
+// FormatBytes 以B/KB/MB/GB/TB格式化字节数（1024进制，结果可由ParseSize解析）
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit && exp < 3; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGT"[exp])
+}
@@ -1,12 +1,12 @@
 package fileutil
 
 import (
-	"crypto/md5"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
-	"strings"
 	"sync"
 	"time"
 )
@@ -18,39 +18,46 @@ type Task struct {
 	DestRoot string // 目标目录根路径
 	Prefix   string // 重命名前缀
 	Suffix   string // 重命名后缀
-	Mode     string // 操作模式: md5/rename/copy/copy_rename/move
-}
+	Mode     string // 操作模式（已注册的Operator名称）: md5/rename/copy/copy_rename/move/sync
 
-// Result 定义处理结果
-type Result struct {
-	OldName  string // 原文件名
-	NewName  string // 新文件名（处理后）
-	SrcMD5   string // 源文件MD5
-	DstMD5   string // 目标文件MD5
-	Verified bool   // 校验结果是否一致
-	Err      error  // 错误信息
-	Retried  int    // 重试次数
-	Skipped  bool   // 是否被跳过
-}
+	Algorithms   []string // 哈希算法列表，一次读取同时计算（为空时使用MD5）
+	StrictVerify bool     // 严格校验：复制完成后重新读取目标文件计算哈希
+	Compare      string   // 同步模式判断文件是否变化的方式（为空时按大小+修改时间）
+	Target       string   // 指定输出路径（来自执行计划，为空时按重命名规则生成）
 
-// ErrorType 定义错误类型
-type ErrorType int
+	Template   *RenameTemplate // 重命名模板（设置后替代前缀/后缀）
+	Regex      *RenameRegex    // 正则重命名规则（不匹配的文件被跳过）
+	Transforms *TransformChain // 文件名变换链（在以上规则之后执行）
+	Seq        int             // 文件序号（按序号设置排序后分配）
+	SeqWidth   int             // 序号补零位数
+	Numbered   bool            // 按序号重命名：前缀 + 序号 + 后缀 + 原扩展名
+	Staged     string          // 两阶段重命名时文件已暂存到的临时路径（此时Target已预先生成）
 
-const (
-	ErrorFileNotFound ErrorType = iota
-	ErrorPermissionDenied
-	ErrorDiskSpaceFull
-	ErrorIORead
-	ErrorIOWrite
-	ErrorCrossDevice
-	ErrorUnknown
-)
+	OnConflict ConflictPolicy // 目标已存在时的处理策略（为空时使用默认策略）
+
+	SpaceCheck  bool  // 复制前检查并预留目标磁盘空间
+	SpaceMargin int64 // 复制后目标文件系统至少保留的可用字节数
 
-// ErrorInfo 定义错误信息结构
-type ErrorInfo struct {
-	Type    ErrorType
-	Message string
-	Path    string
+	Preserve Preserve // 复制时保留的元数据（为nil时保留权限和时间）
+}
+
+// Result 定义处理结果
+type Result struct {
+	OldName       string            // 原文件名
+	NewName       string            // 新文件名（处理后）
+	SrcHashes     map[string]string // 源文件哈希（算法名 -> 十六进制摘要）
+	DstHashes     map[string]string // 目标文件哈希（算法名 -> 十六进制摘要）
+	Verified      bool              // 校验结果是否一致
+	VerifySkipped bool              // 未要求严格校验，没有重新读取目标文件（此时Verified为false不表示不一致）
+	Err           error             // 错误信息
+	Retried       int               // 重试次数
+	Attempts      []RetryAttempt    // 每次失败的执行（成功时为之前失败的重试）
+	Skipped       bool              // 是否被跳过（Err为空时表示不匹配重命名规则，或Conflict为ConflictSkipped）
+	Unchanged     bool              // 同步模式下目标文件未变化，未复制
+	Replaced      bool              // 目标路径上原有的文件被覆盖
+	Conflict      ConflictOutcome   // 目标已存在时的实际处理结果
+	Staged        string            // 两阶段重命名时处理前文件所在的暂存路径
+	MetaErrs      []MetaFailure     // 复制成功但未能保留的元数据
 }
 
 // ErrorPolicy 定义异常策略
@@ -64,116 +71,94 @@ const (
 
 // ProcessFile 处理单个文件任务
 func ProcessFile(t Task) Result {
+	return ProcessFileContext(context.Background(), t)
+}
+
+// ProcessFileContext 处理单个文件任务，ctx取消时中断正在进行的复制和哈希计算
+func ProcessFileContext(ctx context.Context, t Task) Result {
 	result := Result{OldName: t.Path}
 
-	switch t.Mode {
-	case "md5":
-		result = processMD5(t)
-	case "rename":
-		result = processRename(t)
-	case "copy":
-		result = processCopy(t, false)
-	case "copy_rename":
-		result = processCopy(t, true)
-	case "move":
-		result = processMove(t)
-	default:
+	if err := ctx.Err(); err != nil {
+		result.Err = err
+		return result
+	}
+
+	op, ok := LookupOperator(t.Mode)
+	if !ok {
 		result.Err = fmt.Errorf("不支持的操作模式: %s", t.Mode)
+		return result
+	}
+	if err := op.Validate(t); err != nil {
+		result.Err = fmt.Errorf("参数校验失败: %w", err)
+		return result
 	}
 
-	return result
+	return op.Apply(ctx, t)
 }
 
-// ProcessFileWithRetry 带重试机制的文件处理
-func ProcessFileWithRetry(t Task, maxRetries int, retryInterval time.Duration, errorHandler func(ErrorInfo) ErrorPolicy) Result {
-	var result Result
-	var retryCount int
-
-	for retryCount <= maxRetries {
-		result = ProcessFile(t)
+// ProcessFileWithRetry 带重试机制的文件处理，按错误处理器的策略和重试参数决定是否重试
+func ProcessFileWithRetry(t Task, handler *ErrorHandler) Result {
+	return ProcessFileWithRetryContext(context.Background(), t, handler)
+}
 
+// ProcessFileWithRetryContext 带重试机制的文件处理，ctx取消时立即停止处理和重试等待
+// 每次失败的执行都记录在Result.Attempts中
+func ProcessFileWithRetryContext(ctx context.Context, t Task, handler *ErrorHandler) Result {
+	var attempts []RetryAttempt
+	resumed := false // 该任务是否已在熔断后被继续过
+	for {
+		// 熔断暂停期间不开始新的执行
+		if err := handler.waitResume(ctx); err != nil {
+			return Result{OldName: t.Path, Err: err, Retried: len(attempts), Attempts: attempts}
+		}
+		started := time.Now()
+		result := ProcessFileContext(ctx, t)
+		result.Retried = len(attempts)
 		if result.Err == nil {
+			result.Attempts = attempts
 			return result
 		}
 
 		// 分析错误类型
 		errorInfo := analyzeError(result.Err, t.Path)
+		attempts = append(attempts, RetryAttempt{Err: result.Err, Type: errorInfo.Type})
+		result.Attempts = attempts
 
-		// 获取处理策略
-		policy := errorHandler(errorInfo)
+		// 已取消的任务不再交给错误处理器
+		if ctx.Err() != nil {
+			return result
+		}
 
+		// 获取处理策略（同类错误集中出现时熔断，暂停所有Worker等待前端的选择）
+		policy, tripResumed := handler.decide(ctx, errorInfo, started)
 		switch policy {
 		case PolicySkip:
 			result.Skipped = true
 			return result
-		case PolicyAbort:
-			return result
 		case PolicyRetry:
-			if retryCount < maxRetries {
-				retryCount++
-				result.Retried = retryCount
-				time.Sleep(retryInterval)
-				continue
+			if tripResumed {
+				// 熔断后选择继续：按重试策略等待后重新执行；继续后再次熔断说明故障仍在，该任务按失败处理
+				if resumed {
+					return result
+				}
+				resumed = true
+			}
+			delay, ok := handler.nextRetry(errorInfo, result.Retried)
+			if !ok {
+				return result
 			}
+			attempts[len(attempts)-1].Delay = delay
+			if err := sleepContext(ctx, delay); err != nil {
+				return result
+			}
+		default:
 			return result
 		}
 	}
-
-	return result
-}
-
-// analyzeError 分析错误类型
-func analyzeError(err error, path string) ErrorInfo {
-	errStr := err.Error()
-
-	switch {
-	case os.IsNotExist(err):
-		return ErrorInfo{
-			Type:    ErrorFileNotFound,
-			Message: fmt.Sprintf("文件不存在: %s", errStr),
-			Path:    path,
-		}
-	case os.IsPermission(err):
-		return ErrorInfo{
-			Type:    ErrorPermissionDenied,
-			Message: fmt.Sprintf("权限不足: %s", errStr),
-			Path:    path,
-		}
-	case strings.Contains(errStr, "no space left on device"):
-		return ErrorInfo{
-			Type:    ErrorDiskSpaceFull,
-			Message: fmt.Sprintf("磁盘空间不足: %s", errStr),
-			Path:    path,
-		}
-	case strings.Contains(errStr, "read"):
-		return ErrorInfo{
-			Type:    ErrorIORead,
-			Message: fmt.Sprintf("读取错误: %s", errStr),
-			Path:    path,
-		}
-	case strings.Contains(errStr, "write"):
-		return ErrorInfo{
-			Type:    ErrorIOWrite,
-			Message: fmt.Sprintf("写入错误: %s", errStr),
-			Path:    path,
-		}
-	case strings.Contains(errStr, "cross-device"):
-		return ErrorInfo{
-			Type:    ErrorCrossDevice,
-			Message: fmt.Sprintf("跨设备错误: %s", errStr),
-			Path:    path,
-		}
-	default:
-		return ErrorInfo{
-			Type:    ErrorUnknown,
-			Message: fmt.Sprintf("未知错误: %s", errStr),
-			Path:    path,
-		}
-	}
 }
 
-// processMD5 计算文件MD5
-func processMD5(t Task) Result {
+// processMD5 计算文件哈希（一次读取计算所有指定算法）
+func processMD5(ctx context.Context, t Task) Result {
 	result := Result{OldName: t.Path}
 
 	// 检查文件是否存在
@@ -182,21 +167,26 @@ func processMD5(t Task) Result {
 		return result
 	}
 
-	// 计算源文件MD5
-	md5Str, err := calculateFileMD5(t.Path)
+	// 计算源文件哈希
+	sums, err := calculateFileHashes(ctx, t.Path, t.Algorithms)
 	if err != nil {
-		result.Err = fmt.Errorf("计算MD5失败: %w", err)
+		result.Err = fmt.Errorf("计算哈希失败: %w", err)
 		return result
 	}
 
-	result.SrcMD5 = md5Str
+	result.SrcHashes = sums
 	result.NewName = t.Path
 
 	return result
 }
 
-func processRename(t Task) Result {
+func processRename(ctx context.Context, t Task) Result {
 	result := Result{OldName: t.Path}
+	if t.Staged != "" {
+		// 两阶段重命名的第二阶段：从暂存路径移到预先生成的目标路径
+		result.Staged = t.Staged
+		t.Path = t.Staged
+	}
 
 	// 检查源文件是否存在
 	if _, err := os.Stat(t.Path); os.IsNotExist(err) {
@@ -204,20 +194,24 @@ func processRename(t Task) Result {
 		return result
 	}
 
-	// 计算源文件MD5
-	srcMD5, err := calculateFileMD5(t.Path)
+	// 🚨 修复：第三个参数改为true，以应用重命名规则
+	newPath, err := targetPath(ctx, t, true)
+	if errors.Is(err, ErrNoMatch) {
+		result.Skipped = true
+		return result
+	}
 	if err != nil {
-		result.Err = fmt.Errorf("计算源文件MD5失败: %w", err)
+		result.Err = fmt.Errorf("生成新路径失败: %w", err)
 		return result
 	}
-	result.SrcMD5 = srcMD5
 
-	// 🚨 修复：第三个参数改为true，以应用重命名规则
-	newPath, err := generateNewPath(t.Path, t.SrcRoot, t.DestRoot, t.Prefix, t.Suffix, true)
+	// 计算源文件哈希
+	srcHashes, err := calculateFileHashes(ctx, t.Path, t.Algorithms)
 	if err != nil {
-		result.Err = fmt.Errorf("生成新路径失败: %w", err)
+		result.Err = fmt.Errorf("计算源文件哈希失败: %w", err)
 		return result
 	}
+	result.SrcHashes = srcHashes
 
 	// 检查新旧路径是否相同
 	oldAbs, _ := filepath.Abs(t.Path)
@@ -225,7 +219,7 @@ func processRename(t Task) Result {
 	if oldAbs == newAbs {
 		// 新旧路径相同，不需要重命名
 		result.NewName = newPath
-		result.DstMD5 = srcMD5
+		result.DstHashes = srcHashes
 		result.Verified = true
 		return result
 	}
@@ -236,51 +230,62 @@ func processRename(t Task) Result {
 		return result
 	}
 
-	// 检查目标文件是否已存在（但排除自己）
-	if _, err := os.Stat(newPath); err == nil {
-		// 文件已存在，删除它（但这不是自己）
-		if err := os.Remove(newPath); err != nil {
-			result.Err = fmt.Errorf("删除已存在文件失败: %w", err)
-			return result
-		}
+	// 目标文件已存在时按冲突策略处理
+	resolved, outcome, err := resolveConflict(ctx, t, newPath)
+	if err != nil {
+		result.Conflict = outcome
+		result.Err = fmt.Errorf("处理目标冲突失败: %w", err)
+		return result
 	}
-
-	// 检查磁盘空间
-	if err := checkDiskSpace(t.Path, newPath); err != nil {
-		result.Err = fmt.Errorf("磁盘空间检查失败: %w", err)
+	if !applyConflict(&result, resolved, outcome) {
 		return result
 	}
+	newPath = resolved
+	defer func() {
+		// 处理失败时删除保留两者策略创建的占位文件
+		if result.Err != nil && outcome == ConflictRenamed {
+			os.Remove(newPath)
+		}
+	}()
 
 	// 执行重命名
 	if err := os.Rename(t.Path, newPath); err != nil {
 		// 如果跨文件系统，使用复制+删除
-		if strings.Contains(err.Error(), "invalid cross-device link") {
-			if err := copyAndDelete(t.Path, newPath); err != nil {
+		if errors.Is(err, errCrossDevice) {
+			reserved, err := reserveSpace(t, newPath)
+			if err != nil {
+				result.Err = fmt.Errorf("磁盘空间检查失败: %w", err)
+				return result
+			}
+			defer reserved.release()
+			metaErrs, err := copyAndDelete(ctx, t.Path, newPath, t.Algorithms, t.Preserve, reserved)
+			if err != nil {
 				result.Err = fmt.Errorf("跨文件系统重命名失败: %w", err)
 				return result
 			}
+			result.MetaErrs = metaErrs
 		} else {
 			result.Err = fmt.Errorf("重命名失败: %w", err)
 			return result
 		}
 	}
 
-	// 计算新文件MD5
-	dstMD5, err := calculateFileMD5(newPath)
+	// 计算新文件哈希
+	dstHashes, err := calculateFileHashes(ctx, newPath, t.Algorithms)
 	if err != nil {
-		result.Err = fmt.Errorf("计算新文件MD5失败: %w", err)
+		result.Err = fmt.Errorf("计算新文件哈希失败: %w", err)
 		return result
 	}
 
 	result.NewName = newPath
-	result.DstMD5 = dstMD5
-	result.Verified = (srcMD5 == dstMD5)
+	result.DstHashes = dstHashes
+	result.Verified = hashesEqual(srcHashes, dstHashes)
 
 	return result
 }
 
 // processCopy 复制文件
-func processCopy(t Task, rename bool) Result {
+func processCopy(ctx context.Context, t Task, rename bool) Result {
 	result := Result{OldName: t.Path}
 
 	// 检查源文件是否存在
@@ -289,16 +294,12 @@ func processCopy(t Task, rename bool) Result {
 		return result
 	}
 
-	// 计算源文件MD5
-	srcMD5, err := calculateFileMD5(t.Path)
-	if err != nil {
-		result.Err = fmt.Errorf("计算源文件MD5失败: %w", err)
+	// 生成目标路径
+	newPath, err := targetPath(ctx, t, rename)
+	if errors.Is(err, ErrNoMatch) {
+		result.Skipped = true
 		return result
 	}
-	result.SrcMD5 = srcMD5
-
-	// 生成目标路径
-	newPath, err := generateNewPath(t.Path, t.SrcRoot, t.DestRoot, t.Prefix, t.Suffix, rename)
 	if err != nil {
 		result.Err = fmt.Errorf("生成目标路径失败: %w", err)
 		return result
@@ -310,34 +311,51 @@ func processCopy(t Task, rename bool) Result {
 		return result
 	}
 
-	// 检查磁盘空间
-	if err := checkDiskSpace(t.Path, newPath); err != nil {
-		result.Err = fmt.Errorf("磁盘空间检查失败: %w", err)
+	// 目标文件已存在时按冲突策略处理
+	resolved, outcome, err := resolveConflict(ctx, t, newPath)
+	if err != nil {
+		result.Conflict = outcome
+		result.Err = fmt.Errorf("处理目标冲突失败: %w", err)
+		return result
+	}
+	if !applyConflict(&result, resolved, outcome) {
 		return result
 	}
+	newPath = resolved
+	defer func() {
+		// 处理失败时删除保留两者策略创建的占位文件
+		if result.Err != nil && outcome == ConflictRenamed {
+			os.Remove(newPath)
+		}
+	}()
 
-	// 执行复制
-	if err := copyFile(t.Path, newPath); err != nil {
-		result.Err = fmt.Errorf("复制文件失败: %w", err)
+	// 检查并预留磁盘空间
+	reserved, err := reserveSpace(t, newPath)
+	if err != nil {
+		result.Err = fmt.Errorf("磁盘空间检查失败: %w", err)
 		return result
 	}
+	defer reserved.release()
 
-	// 计算目标文件MD5
-	dstMD5, err := calculateFileMD5(newPath)
+	// 执行复制（边复制边计算源文件哈希，严格校验时重新读取目标文件）
+	srcHashes, dstHashes, metaErrs, err := copyFile(ctx, t.Path, newPath, t.Algorithms, t.StrictVerify, t.Preserve, reserved)
 	if err != nil {
-		result.Err = fmt.Errorf("计算目标文件MD5失败: %w", err)
+		result.Err = fmt.Errorf("复制文件失败: %w", err)
 		return result
 	}
+	result.MetaErrs = metaErrs
 
+	result.SrcHashes = srcHashes
 	result.NewName = newPath
-	result.DstMD5 = dstMD5
-	result.Verified = (srcMD5 == dstMD5)
+	result.DstHashes = dstHashes
+	result.Verified = hashesEqual(srcHashes, dstHashes)
+	result.VerifySkipped = dstHashes == nil
 
 	return result
 }
 
 // processMove 移动文件
-func processMove(t Task) Result {
+func processMove(ctx context.Context, t Task) Result {
 	result := Result{OldName: t.Path}
 
 	// 检查源文件是否存在
@@ -346,20 +364,24 @@ func processMove(t Task) Result {
 		return result
 	}
 
-	// 计算源文件MD5
-	srcMD5, err := calculateFileMD5(t.Path)
+	// 生成目标路径
+	newPath, err := targetPath(ctx, t, true)
+	if errors.Is(err, ErrNoMatch) {
+		result.Skipped = true
+		return result
+	}
 	if err != nil {
-		result.Err = fmt.Errorf("计算源文件MD5失败: %w", err)
+		result.Err = fmt.Errorf("生成目标路径失败: %w", err)
 		return result
 	}
-	result.SrcMD5 = srcMD5
 
-	// 生成目标路径
-	newPath, err := generateNewPath(t.Path, t.SrcRoot, t.DestRoot, t.Prefix, t.Suffix, true)
+	// 计算源文件哈希
+	srcHashes, err := calculateFileHashes(ctx, t.Path, t.Algorithms)
 	if err != nil {
-		result.Err = fmt.Errorf("生成目标路径失败: %w", err)
+		result.Err = fmt.Errorf("计算源文件哈希失败: %w", err)
 		return result
 	}
+	result.SrcHashes = srcHashes
 
 	// 创建目标目录
 	if err := createDirectory(filepath.Dir(newPath)); err != nil {
@@ -367,44 +389,41 @@ func processMove(t Task) Result {
 		return result
 	}
 
-	// 检查磁盘空间
-	if err := checkDiskSpace(t.Path, newPath); err != nil {
-		result.Err = fmt.Errorf("磁盘空间检查失败: %w", err)
+	// 目标文件已存在时按冲突策略处理
+	resolved, outcome, err := resolveConflict(ctx, t, newPath)
+	if err != nil {
+		result.Conflict = outcome
+		result.Err = fmt.Errorf("处理目标冲突失败: %w", err)
 		return result
 	}
-
-	// 检查目标文件是否已存在
-	if _, err := os.Stat(newPath); err == nil {
-		// 文件已存在，删除它
-		if err := os.Remove(newPath); err != nil {
-			result.Err = fmt.Errorf("删除已存在文件失败: %w", err)
-			return result
-		}
+	if !applyConflict(&result, resolved, outcome) {
+		return result
 	}
+	newPath = resolved
+	defer func() {
+		// 处理失败时删除保留两者策略创建的占位文件
+		if result.Err != nil && outcome == ConflictRenamed {
+			os.Remove(newPath)
+		}
+	}()
 
 	// 尝试直接移动
 	if err := os.Rename(t.Path, newPath); err != nil {
 		// 如果跨文件系统，使用复制+删除
-		if strings.Contains(err.Error(), "invalid cross-device link") {
-			// 先复制
-			if err := copyFile(t.Path, newPath); err != nil {
-				result.Err = fmt.Errorf("跨文件系统移动-复制失败: %w", err)
-				return result
-			}
-
-			// 计算目标文件MD5
-			dstMD5, err := calculateFileMD5(newPath)
+		if errors.Is(err, errCrossDevice) {
+			reserved, err := reserveSpace(t, newPath)
 			if err != nil {
-				result.Err = fmt.Errorf("计算目标文件MD5失败: %w", err)
+				result.Err = fmt.Errorf("磁盘空间检查失败: %w", err)
 				return result
 			}
-
-			// 验证MD5
-			if srcMD5 != dstMD5 {
-				os.Remove(newPath)
-				result.Err = fmt.Errorf("移动后MD5校验不一致")
+			defer reserved.release()
+			// 先复制（删除源文件前始终做严格校验，校验不一致时不会生成目标文件）
+			_, dstHashes, metaErrs, err := copyFile(ctx, t.Path, newPath, t.Algorithms, true, t.Preserve, reserved)
+			if err != nil {
+				result.Err = fmt.Errorf("跨文件系统移动-复制失败: %w", err)
 				return result
 			}
+			result.MetaErrs = metaErrs
 
 			// 删除源文件
 			if err := os.Remove(t.Path); err != nil {
@@ -412,42 +431,49 @@ func processMove(t Task) Result {
 				return result
 			}
 
-			result.DstMD5 = dstMD5
-			result.Verified = true
+			result.DstHashes = dstHashes
+			result.Verified = hashesEqual(srcHashes, dstHashes)
 		} else {
 			result.Err = fmt.Errorf("移动文件失败: %w", err)
 			return result
 		}
 	} else {
 		// 直接移动成功
-		dstMD5, err := calculateFileMD5(newPath)
+		dstHashes, err := calculateFileHashes(ctx, newPath, t.Algorithms)
 		if err != nil {
-			result.Err = fmt.Errorf("计算目标文件MD5失败: %w", err)
+			result.Err = fmt.Errorf("计算目标文件哈希失败: %w", err)
 			return result
 		}
 
-		result.DstMD5 = dstMD5
-		result.Verified = (srcMD5 == dstMD5)
+		result.DstHashes = dstHashes
+		result.Verified = hashesEqual(srcHashes, dstHashes)
 	}
 
 	result.NewName = newPath
 	return result
 }
 
-// calculateFileMD5 计算文件MD5
-func calculateFileMD5(filePath string) (string, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return "", err
+// targetPath 返回任务的输出路径：优先使用执行计划指定的路径，否则按规则生成
+func targetPath(ctx context.Context, t Task, applyNaming bool) (string, error) {
+	if t.Target != "" {
+		return t.Target, nil
 	}
-	defer file.Close()
-
-	hash := md5.New()
-	if _, err := io.Copy(hash, file); err != nil {
-		return "", err
+	var newPath string
+	var err error
+	switch {
+	case applyNaming && t.Template != nil:
+		newPath, err = templatePath(ctx, t)
+	case applyNaming && t.Numbered:
+		newPath, err = numberPath(t)
+	case applyNaming && t.Regex != nil:
+		newPath, err = regexPath(t)
+	default:
+		newPath, err = generateNewPath(t.Path, t.SrcRoot, t.DestRoot, t.Prefix, t.Suffix, applyNaming)
 	}
-
-	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+	if err != nil || !applyNaming || t.Transforms == nil {
+		return newPath, err
+	}
+	return transformPath(newPath, t.Transforms)
 }
 
 // generateNewPath 生成新的文件路径
@@ -487,64 +513,141 @@ func generateNewPath(oldPath, srcRoot, destRoot, prefix, suffix string, applyNam
 	return newPath, nil
 }
 
-// copyFile 复制文件
-func copyFile(src, dst string) error {
+// copyBufferPool 复制缓冲区池，减少大批量复制时的内存分配
+var copyBufferPool = sync.Pool{
+	New: func() any {
+		buf := make([]byte, 256*1024)
+		return &buf
+	},
+}
+
+// copyFile 原子复制文件：边复制边计算源文件哈希，写入目标目录下的临时文件并同步到磁盘后，
+// 再重命名到目标路径。verify为true时重新读取临时文件计算目标哈希并与源哈希比对，
+// 否则只读取一遍源文件，dstHashes返回nil。失败或ctx取消时删除临时文件，目标路径保持原状。
+// preserve指定保留的元数据（nil表示DefaultPreserve），未能保留的元数据不影响复制结果，通过metaErrs返回；
+// reserved为复制前预留的磁盘空间（可以为nil），随写入逐步释放
+func copyFile(ctx context.Context, src, dst string, algos []string, verify bool, preserve Preserve, reserved *spaceReservation) (srcHashes, dstHashes map[string]string, metaErrs []MetaFailure, err error) {
+	preserve = preserve.orDefault()
+	hasher, err := newMultiHasher(algos)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
 	srcFile, err := os.Open(src)
 	if err != nil {
-		return err
+		return nil, nil, nil, err
 	}
 	defer srcFile.Close()
 
-	dstFile, err := os.Create(dst)
+	srcInfo, err := srcFile.Stat()
 	if err != nil {
-		return err
+		return nil, nil, nil, err
+	}
+	// 读取内容会更新访问时间，需在复制之前获取
+	var atime time.Time
+	if preserve.Has(MetaTimes) {
+		atime = accessTime(srcFile)
 	}
-	defer dstFile.Close()
 
-	_, err = io.Copy(dstFile, srcFile)
+	tmpFile, err := createTempFile(dst)
 	if err != nil {
-		return err
+		return nil, nil, nil, err
 	}
+	tmpPath := tmpFile.Name()
+	defer func() {
+		if err != nil {
+			tmpFile.Close()
+			os.Remove(tmpPath)
+		}
+	}()
 
-	// 同步到磁盘
-	if err := dstFile.Sync(); err != nil {
-		return err
+	// 写入临时文件的同时计算源文件哈希，并释放已写入部分的空间预留
+	var w io.Writer = io.MultiWriter(tmpFile, hasher)
+	if reserved != nil {
+		w = io.MultiWriter(tmpFile, hasher, reserved)
 	}
-
-	// 复制文件权限
-	srcInfo, err := os.Stat(src)
+	buf := copyBufferPool.Get().(*[]byte)
+	_, err = io.CopyBuffer(w, &contextReader{ctx: ctx, r: srcFile}, *buf)
+	copyBufferPool.Put(buf)
 	if err != nil {
-		return err
+		return nil, nil, nil, err
 	}
+	srcHashes = hasher.Sums()
 
-	return os.Chmod(dst, srcInfo.Mode())
-}
+	// 同步到磁盘
+	if err = tmpFile.Sync(); err != nil {
+		return nil, nil, nil, err
+	}
 
-// copyAndDelete 复制文件然后删除源文件
-func copyAndDelete(src, dst string) error {
-	if err := copyFile(src, dst); err != nil {
-		return err
+	// 保留属主、扩展属性、ACL和权限
+	metaErrs = preserveMetadata(srcFile, tmpFile, srcInfo, preserve)
+	if err = tmpFile.Close(); err != nil {
+		return nil, nil, nil, err
 	}
 
-	// 验证复制后的文件
-	srcMD5, err := calculateFileMD5(src)
-	if err != nil {
-		return err
+	// 严格校验：重新读取临时文件
+	if verify {
+		dstHashes, err = calculateFileHashes(ctx, tmpPath, algos)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if !hashesEqual(srcHashes, dstHashes) {
+			err = fmt.Errorf("复制后哈希校验不一致")
+			return nil, nil, nil, err
+		}
 	}
 
-	dstMD5, err := calculateFileMD5(dst)
-	if err != nil {
-		os.Remove(dst)
-		return err
+	// 保留时间（重命名不改变时间，校验读取之后再设置）
+	metaErrs = append(metaErrs, preserveTimes(tmpPath, atime, srcInfo.ModTime(), preserve)...)
+
+	// 原子替换目标文件
+	if err = os.Rename(tmpPath, dst); err != nil {
+		return nil, nil, nil, err
 	}
+	return srcHashes, dstHashes, metaErrs, nil
+}
 
-	if srcMD5 != dstMD5 {
-		os.Remove(dst)
-		return fmt.Errorf("复制后MD5校验不一致")
+// CopyFile 原子复制单个文件，保留preserve指定的元数据，返回未能保留的元数据
+func CopyFile(ctx context.Context, src, dst string, preserve Preserve) ([]MetaFailure, error) {
+	_, _, metaErrs, err := copyFile(ctx, src, dst, nil, false, preserve, nil)
+	return metaErrs, err
+}
+
+// copyAndDelete 复制文件并严格校验，然后删除源文件（reserved同copyFile）
+func copyAndDelete(ctx context.Context, src, dst string, algos []string, preserve Preserve, reserved *spaceReservation) ([]MetaFailure, error) {
+	_, _, metaErrs, err := copyFile(ctx, src, dst, algos, true, preserve, reserved)
+	if err != nil {
+		return nil, err
 	}
 
 	// 删除源文件
-	return os.Remove(src)
+	return metaErrs, os.Remove(src)
+}
+
+// contextReader 在每次读取前检查ctx，使io.Copy能够被取消
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr *contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
+
+// sleepContext 等待指定时长，ctx取消时提前返回
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 // createDirectory 创建目录，处理权限问题
@@ -564,40 +667,23 @@ func createDirectory(dirPath string) error {
 	return nil
 }
 
-// checkDiskSpace 检查磁盘空间
-func checkDiskSpace(srcPath, dstPath string) error {
-	// // 获取源文件大小
-	// fileInfo, err := os.Stat(srcPath)
-	// if err != nil {
-	// 	return err
-	// }
-	// fileSize := fileInfo.Size()
-
-	// // 获取目标路径所在磁盘的剩余空间（跨平台实现）
-	// freeSpace, err := getFreeDiskSpace(filepath.Dir(dstPath))
-	// if err != nil {
-	// 	// 如果无法获取磁盘信息，跳过检查
-	// 	return nil
-	// }
-
-	// // 预留10%的安全空间
-	// safetyMargin := freeSpace / 10
-	// requiredSpace := uint64(fileSize) + safetyMargin
-
-	// if freeSpace < requiredSpace {
-	// 	return fmt.Errorf("磁盘空间不足: 需要%d字节，可用%d字节", requiredSpace, freeSpace)
-	// }
-
-	return nil
-}
-
 // ErrorHandler 默认错误处理器
 type ErrorHandler struct {
-	policies      map[ErrorType]ErrorPolicy
-	maxRetries    int
-	retryInterval time.Duration
-	mu            sync.Mutex
-	abortFlag     bool
+	policies     map[ErrorType]ErrorPolicy
+	retries      map[ErrorType]RetryPolicy
+	budget       RetryBudget
+	spentRetries int
+	spentWait    time.Duration
+	exhausted    bool
+	breaker      BreakerConfig
+	onTrip       TripFunc
+	paused       *pause
+	bursts       map[ErrorType][]time.Time
+	muted        map[ErrorType]bool
+	decided      map[ErrorType]tripRecord
+	trips        int
+	mu           sync.Mutex
+	abortFlag    bool
 }
 
 // NewErrorHandler 创建新的错误处理器
@@ -611,9 +697,18 @@ func NewErrorHandler() *ErrorHandler {
 			ErrorIOWrite:          PolicyRetry,
 			ErrorCrossDevice:      PolicySkip,
 			ErrorUnknown:          PolicyRetry,
+			ErrorTargetExists:     PolicySkip,
+			ErrorReadOnlyFS:       PolicyAbort,
+			ErrorTooManyFiles:     PolicyRetry,
+			ErrorNameTooLong:      PolicySkip,
+			ErrorFileBusy:         PolicyRetry,
 		},
-		maxRetries:    3,
-		retryInterval: 2 * time.Second,
+		retries: map[ErrorType]RetryPolicy{},
+		budget:  DefaultRetryBudget,
+		breaker: DefaultBreaker,
+		bursts:  map[ErrorType][]time.Time{},
+		muted:   map[ErrorType]bool{},
+		decided: map[ErrorType]tripRecord{},
 	}
 }
 
@@ -631,13 +726,21 @@ func (h *ErrorHandler) HandleError(errorInfo ErrorInfo) ErrorPolicy {
 		return PolicyRetry
 	}
 
-	if errorInfo.Type == ErrorDiskSpaceFull {
+	// 未设置熔断处理函数时，磁盘空间不足直接终止批处理
+	if policy == PolicyAbort || (errorInfo.Type == ErrorDiskSpaceFull && h.onTrip == nil) {
 		h.abortFlag = true
 	}
 
 	return policy
 }
 
+// aborting 返回是否已有错误要求终止批处理
+func (h *ErrorHandler) aborting() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.abortFlag
+}
+
 // SetPolicy 设置特定错误类型的策略
 func (h *ErrorHandler) SetPolicy(errorType ErrorType, policy ErrorPolicy) {
 	h.mu.Lock()
@@ -645,18 +748,41 @@ func (h *ErrorHandler) SetPolicy(errorType ErrorType, policy ErrorPolicy) {
 	h.policies[errorType] = policy
 }
 
-// SetMaxRetries 设置最大重试次数
-func (h *ErrorHandler) SetMaxRetries(max int) {
+// Policy 返回特定错误类型当前的策略（未设置的类型按重试处理）
+func (h *ErrorHandler) Policy(errorType ErrorType) ErrorPolicy {
 	h.mu.Lock()
 	defer h.mu.Unlock()
-	h.maxRetries = max
+	return h.policy(errorType)
 }
 
-// SetRetryInterval 设置重试间隔
+// policy 返回特定错误类型当前的策略（调用方持有h.mu）
+func (h *ErrorHandler) policy(errorType ErrorType) ErrorPolicy {
+	if policy, ok := h.policies[errorType]; ok {
+		return policy
+	}
+	return PolicyRetry
+}
+
+// SetMaxRetries 设置所有错误类型的最大重试次数
+func (h *ErrorHandler) SetMaxRetries(retries int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, et := range ErrorTypes() {
+		policy := h.retryPolicy(et)
+		policy.MaxAttempts = max(retries, 0) + 1
+		h.retries[et] = policy
+	}
+}
+
+// SetRetryInterval 设置所有错误类型第一次重试前的等待时间
 func (h *ErrorHandler) SetRetryInterval(interval time.Duration) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
-	h.retryInterval = interval
+	for _, et := range ErrorTypes() {
+		policy := h.retryPolicy(et)
+		policy.InitialDelay = interval
+		h.retries[et] = policy
+	}
 }
 
 // Reset 重置错误处理器
@@ -664,15 +790,10 @@ func (h *ErrorHandler) Reset() {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 	h.abortFlag = false
-}
-
-// getFreeDiskSpace 获取磁盘可用空间（跨平台）
-func getFreeDiskSpace(_ string) (uint64, error) {
-
-	return 0, nil
-}
-
-// AnalyzeError 分析错误类型（导出版本）
-func AnalyzeError(err error, path string) ErrorInfo {
-	return analyzeError(err, path)
+	h.spentRetries = 0
+	h.spentWait = 0
+	h.exhausted = false
+	h.bursts = map[ErrorType][]time.Time{}
+	h.decided = map[ErrorType]tripRecord{}
+	h.trips = 0
 }
@@ -0,0 +1,276 @@
+package fileutil
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RegexScope 正则重命名作用的范围
+type RegexScope string
+
+const (
+	RegexScopeName RegexScope = "name" // 不含扩展名的文件名
+	RegexScopeExt  RegexScope = "ext"  // 扩展名（不含.）
+	RegexScopePath RegexScope = "path" // 相对源目录的完整路径（/分隔），结果可以移动到其他子目录
+)
+
+// regexScopeLabels 正则作用范围的显示名称
+var regexScopeLabels = map[RegexScope]string{
+	RegexScopeName: "文件名（不含扩展名）",
+	RegexScopeExt:  "扩展名",
+	RegexScopePath: "相对路径",
+}
+
+// RegexScopes 按显示顺序返回所有正则作用范围
+func RegexScopes() []RegexScope {
+	return []RegexScope{RegexScopeName, RegexScopeExt, RegexScopePath}
+}
+
+// Label 返回正则作用范围的显示名称
+func (s RegexScope) Label() string {
+	if label, ok := regexScopeLabels[s]; ok {
+		return label
+	}
+	return string(s)
+}
+
+// ErrNoMatch 文件名不匹配正则重命名规则，该文件会被跳过
+var ErrNoMatch = errors.New("不匹配重命名规则")
+
+// RegexRule 正则查找替换的重命名规则
+type RegexRule struct {
+	Pattern    string     `json:"pattern"`               // 正则表达式（Go RE2语法）
+	Replace    string     `json:"replace"`               // 替换内容，$1、${name} 引用捕获组
+	IgnoreCase bool       `json:"ignore_case,omitempty"` // 忽略大小写
+	All        bool       `json:"all,omitempty"`         // 替换所有匹配（否则只替换第一个）
+	Scope      RegexScope `json:"scope,omitempty"`       // 作用范围（为空时为文件名）
+}
+
+// ParseRegexExpr 解析sed风格的替换表达式 s/pattern/replace/flags
+// 分隔符可以是s后的任意标点，表达式中的分隔符用\转义；flags支持i（忽略大小写）和g（替换所有匹配）
+func ParseRegexExpr(expr string) (RegexRule, error) {
+	var rule RegexRule
+	if len(expr) < 2 || expr[0] != 's' {
+		return rule, fmt.Errorf("替换表达式格式应为 s/正则/替换内容/标志: %s", expr)
+	}
+	delim := expr[1]
+	if delim == '\\' || delim == ' ' || delim >= 0x80 || (delim >= '0' && delim <= '9') ||
+		(delim >= 'a' && delim <= 'z') || (delim >= 'A' && delim <= 'Z') {
+		return rule, fmt.Errorf("替换表达式的分隔符无效: %q", delim)
+	}
+
+	// 按未转义的分隔符切分，转义的分隔符还原为分隔符本身，其余转义原样保留
+	var parts []string
+	var b strings.Builder
+	for i := 2; i < len(expr); i++ {
+		c := expr[i]
+		if c == '\\' && i+1 < len(expr) {
+			if expr[i+1] == delim {
+				b.WriteByte(delim)
+			} else {
+				b.WriteByte(c)
+				b.WriteByte(expr[i+1])
+			}
+			i++
+			continue
+		}
+		if c == delim {
+			parts = append(parts, b.String())
+			b.Reset()
+			continue
+		}
+		b.WriteByte(c)
+	}
+	parts = append(parts, b.String())
+	if len(parts) == 2 {
+		parts = append(parts, "")
+	}
+	if len(parts) != 3 {
+		return rule, fmt.Errorf("替换表达式格式应为 s/正则/替换内容/标志: %s", expr)
+	}
+
+	rule.Pattern, rule.Replace = parts[0], parts[1]
+	for _, f := range parts[2] {
+		switch f {
+		case 'i':
+			rule.IgnoreCase = true
+		case 'g':
+			rule.All = true
+		default:
+			return rule, fmt.Errorf("不支持的替换标志: %c（可选：i/g）", f)
+		}
+	}
+	return rule, nil
+}
+
+// sameRegexRule 判断两个正则规则是否相同（都为空也视为相同）
+func sameRegexRule(a, b *RegexRule) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// RenameRegex 编译后的正则重命名规则
+type RenameRegex struct {
+	rule RegexRule
+	re   *regexp.Regexp
+}
+
+// Compile 校验并编译正则重命名规则
+func (r RegexRule) Compile() (*RenameRegex, error) {
+	if r.Pattern == "" {
+		return nil, fmt.Errorf("正则表达式不能为空")
+	}
+	switch r.Scope {
+	case "":
+		r.Scope = RegexScopeName
+	case RegexScopeName, RegexScopeExt, RegexScopePath:
+	default:
+		return nil, fmt.Errorf("不支持的正则作用范围: %s（可选：%s/%s/%s）", r.Scope, RegexScopeName, RegexScopeExt, RegexScopePath)
+	}
+	pattern := r.Pattern
+	if r.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("正则表达式错误: %w", err)
+	}
+	if err := checkReplaceGroups(re, r.Replace); err != nil {
+		return nil, err
+	}
+	return &RenameRegex{rule: r, re: re}, nil
+}
+
+// checkReplaceGroups 检查替换内容引用的捕获组都存在
+// regexp.Expand会把不存在的组替换为空，"$1_x" 这类写法会被当作名为 "1_x" 的组而静默丢失
+func checkReplaceGroups(re *regexp.Regexp, repl string) error {
+	names := map[string]bool{}
+	for _, name := range re.SubexpNames() {
+		if name != "" {
+			names[name] = true
+		}
+	}
+	for i := 0; i < len(repl); i++ {
+		if repl[i] != '$' || i+1 >= len(repl) {
+			continue
+		}
+		rest := repl[i+1:]
+		if rest[0] == '$' {
+			i++
+			continue
+		}
+		var name string
+		if rest[0] == '{' {
+			end := strings.IndexByte(rest, '}')
+			if end < 0 {
+				return fmt.Errorf("替换内容中的 ${ 没有闭合")
+			}
+			name = rest[1:end]
+		} else {
+			end := 0
+			for end < len(rest) && (rest[end] == '_' || isAlnum(rest[end])) {
+				end++
+			}
+			name = rest[:end]
+		}
+		if name == "" {
+			continue
+		}
+		if n, err := strconv.Atoi(name); err == nil {
+			if n > re.NumSubexp() {
+				return fmt.Errorf("替换内容引用了不存在的捕获组 $%d（共%d个）", n, re.NumSubexp())
+			}
+			continue
+		}
+		if !names[name] {
+			return fmt.Errorf("替换内容引用了不存在的捕获组 %q（紧跟字母、数字或_时请写成 ${1} 的形式）", name)
+		}
+	}
+	return nil
+}
+
+// isAlnum 判断是否为ASCII字母或数字
+func isAlnum(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// replace 对字符串执行替换，不匹配时返回false
+func (rr *RenameRegex) replace(s string) (string, bool) {
+	if rr.rule.All {
+		if !rr.re.MatchString(s) {
+			return "", false
+		}
+		return rr.re.ReplaceAllString(s, rr.rule.Replace), true
+	}
+	m := rr.re.FindStringSubmatchIndex(s)
+	if m == nil {
+		return "", false
+	}
+	out := rr.re.ExpandString([]byte(s[:m[0]]), rr.rule.Replace, s, m)
+	return string(out) + s[m[1]:], true
+}
+
+// regexPath 按正则规则生成新的文件路径，不匹配时返回ErrNoMatch
+// 替换后仍会追加前缀/后缀
+func regexPath(t Task) (string, error) {
+	relPath := filepath.Base(t.Path)
+	if t.SrcRoot != "" {
+		var err error
+		if relPath, err = filepath.Rel(t.SrcRoot, t.Path); err != nil {
+			return "", err
+		}
+	}
+	dir := filepath.Dir(relPath)
+	filename := filepath.Base(relPath)
+	ext := filepath.Ext(filename)
+	name := filename[:len(filename)-len(ext)]
+
+	var ok bool
+	switch t.Regex.rule.Scope {
+	case RegexScopeExt:
+		var newExt string
+		if newExt, ok = t.Regex.replace(strings.TrimPrefix(ext, ".")); ok && newExt != "" {
+			newExt = "." + newExt
+		}
+		ext = newExt
+	case RegexScopePath:
+		var newRel string
+		if newRel, ok = t.Regex.replace(filepath.ToSlash(relPath)); ok {
+			newRel = filepath.FromSlash(newRel)
+			if !filepath.IsLocal(newRel) || strings.HasSuffix(newRel, string(filepath.Separator)) {
+				return "", fmt.Errorf("替换结果不是有效的相对路径: %q", newRel)
+			}
+			dir = filepath.Dir(newRel)
+			filename = filepath.Base(newRel)
+			ext = filepath.Ext(filename)
+			name = filename[:len(filename)-len(ext)]
+		}
+	default:
+		name, ok = t.Regex.replace(name)
+	}
+	if !ok {
+		return "", ErrNoMatch
+	}
+
+	filename = t.Prefix + name + t.Suffix + ext
+	if filename == "" || filename == "." || filename == ".." {
+		return "", fmt.Errorf("替换后的文件名为空或无效: %q", filename)
+	}
+	if strings.ContainsAny(filename, `/\`+"\x00") {
+		return "", fmt.Errorf("替换后的文件名包含非法字符: %q", filename)
+	}
+
+	switch {
+	case t.DestRoot != "":
+		return filepath.Join(t.DestRoot, dir, filename), nil
+	case t.SrcRoot != "":
+		return filepath.Join(t.SrcRoot, dir, filename), nil
+	}
+	return filepath.Join(filepath.Dir(t.Path), dir, filename), nil
+}
@@ -0,0 +1,174 @@
+package fileutil
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestParseRegexExpr 按未转义的分隔符切分，转义的分隔符还原为分隔符本身，其余转义原样保留
+func TestParseRegexExpr(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    RegexRule
+		wantErr string
+	}{
+		{"基本替换", "s/foo/bar/", RegexRule{Pattern: "foo", Replace: "bar"}, ""},
+		{"省略末尾分隔符", "s/foo/bar", RegexRule{Pattern: "foo", Replace: "bar"}, ""},
+		{"替换为空", "s/foo//", RegexRule{Pattern: "foo"}, ""},
+		{"标志", "s/foo/bar/gi", RegexRule{Pattern: "foo", Replace: "bar", IgnoreCase: true, All: true}, ""},
+		{"其他分隔符", "s#a/b#c/d#", RegexRule{Pattern: "a/b", Replace: "c/d"}, ""},
+		{"转义的分隔符", `s/a\/b/c\/d/`, RegexRule{Pattern: "a/b", Replace: "c/d"}, ""},
+		{"其余转义原样保留", `s/\d+\.txt/\$1/`, RegexRule{Pattern: `\d+\.txt`, Replace: `\$1`}, ""},
+		{"捕获组引用", "s/(\\w+)-(\\d+)/${2}_$1/", RegexRule{Pattern: `(\w+)-(\d+)`, Replace: "${2}_$1"}, ""},
+		{"不以s开头", "x/foo/bar/", RegexRule{}, "格式应为"},
+		{"太短", "s", RegexRule{}, "格式应为"},
+		{"字母分隔符", "safoobara", RegexRule{}, "分隔符无效"},
+		{"反斜杠分隔符", `s\foo\bar\`, RegexRule{}, "分隔符无效"},
+		{"缺少替换内容", "s/foo", RegexRule{}, "格式应为"},
+		{"多余的部分", "s/a/b/g/c", RegexRule{}, "格式应为"},
+		{"不支持的标志", "s/a/b/x", RegexRule{}, "不支持的替换标志"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRegexExpr(tt.expr)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseRegexExpr(%q) 错误 = %v, 期望包含 %q", tt.expr, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRegexExpr(%q) 返回错误: %v", tt.expr, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRegexExpr(%q) = %+v, 期望 %+v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRegexRuleCompile 替换内容引用不存在的捕获组时拒绝编译，避免被regexp.Expand静默替换为空
+func TestRegexRuleCompile(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    RegexRule
+		wantErr string
+	}{
+		{"无捕获组", RegexRule{Pattern: "foo", Replace: "bar"}, ""},
+		{"编号引用", RegexRule{Pattern: `(\w+)-(\d+)`, Replace: "$2_$1"}, "不存在的捕获组 \"2_\""},
+		{"花括号编号引用", RegexRule{Pattern: `(\w+)-(\d+)`, Replace: "${2}_${1}"}, ""},
+		{"命名引用", RegexRule{Pattern: `(?P<year>\d{4})`, Replace: "${year}"}, ""},
+		{"命名引用后跟字母", RegexRule{Pattern: `(?P<year>\d{4})`, Replace: "$year"}, ""},
+		{"转义的$", RegexRule{Pattern: "a", Replace: "$$9"}, ""},
+		{"末尾的$", RegexRule{Pattern: "a", Replace: "b$"}, ""},
+		{"$0为整个匹配", RegexRule{Pattern: "a", Replace: "[$0]"}, ""},
+		{"编号超出范围", RegexRule{Pattern: `(a)`, Replace: "$2"}, "不存在的捕获组 $2（共1个）"},
+		{"$1后紧跟字母", RegexRule{Pattern: `(a)`, Replace: "$1x"}, "不存在的捕获组 \"1x\""},
+		{"不存在的命名组", RegexRule{Pattern: `(?P<a>x)`, Replace: "${b}"}, "不存在的捕获组 \"b\""},
+		{"花括号没有闭合", RegexRule{Pattern: `(a)`, Replace: "${1"}, "没有闭合"},
+		{"空正则", RegexRule{Replace: "x"}, "不能为空"},
+		{"正则语法错误", RegexRule{Pattern: "(a"}, "正则表达式错误"},
+		{"不支持的作用范围", RegexRule{Pattern: "a", Scope: "dir"}, "不支持的正则作用范围"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.rule.Compile()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Compile() 返回错误: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Compile() 错误 = %v, 期望包含 %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestRegexPath 按作用范围替换后追加前缀/后缀，不匹配时返回ErrNoMatch
+func TestRegexPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    RegexRule
+		rel     string
+		prefix  string
+		want    string
+		wantErr string
+	}{
+		{"文件名", RegexRule{Pattern: `IMG_(\d+)`, Replace: "photo_$1"}, "a/IMG_01.jpg", "", "a/photo_01.jpg", ""},
+		{"只替换第一个", RegexRule{Pattern: "a", Replace: "b"}, "aaa.txt", "", "baa.txt", ""},
+		{"替换所有", RegexRule{Pattern: "a", Replace: "b", All: true}, "aaa.txt", "", "bbb.txt", ""},
+		{"忽略大小写", RegexRule{Pattern: "img", Replace: "pic", IgnoreCase: true}, "IMG.jpg", "", "pic.jpg", ""},
+		{"不影响扩展名", RegexRule{Pattern: "jpg", Replace: "png"}, "jpg.jpg", "", "png.jpg", ""},
+		{"扩展名", RegexRule{Pattern: "^jpeg$", Replace: "jpg", Scope: RegexScopeExt}, "a.jpeg", "", "a.jpg", ""},
+		{"去掉扩展名", RegexRule{Pattern: ".*", Replace: "", Scope: RegexScopeExt}, "a.jpeg", "", "a", ""},
+		{"相对路径", RegexRule{Pattern: `^(\d{4})-(.*)$`, Replace: "$1/$2", Scope: RegexScopePath}, "2024-a.txt", "", "2024/a.txt", ""},
+		{"追加前缀", RegexRule{Pattern: "a", Replace: "b"}, "a.txt", "x_", "x_b.txt", ""},
+		{"不匹配", RegexRule{Pattern: "^z", Replace: "y"}, "a.txt", "", "", ErrNoMatch.Error()},
+		{"路径移出源目录", RegexRule{Pattern: "^", Replace: "../", Scope: RegexScopePath}, "a.txt", "", "", "不是有效的相对路径"},
+		{"文件名为空", RegexRule{Pattern: ".*", Replace: ""}, "a", "", "", "为空或无效"},
+		{"文件名包含分隔符", RegexRule{Pattern: "a", Replace: "b/c"}, "a.txt", "", "", "非法字符"},
+	}
+
+	root := filepath.Join(t.TempDir(), "src")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr, err := tt.rule.Compile()
+			if err != nil {
+				t.Fatal(err)
+			}
+			task := Task{Path: filepath.Join(root, filepath.FromSlash(tt.rel)), SrcRoot: root, Prefix: tt.prefix, Regex: rr}
+			got, err := regexPath(task)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("regexPath() 错误 = %v, 期望包含 %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("regexPath() 返回错误: %v", err)
+			}
+			if want := filepath.Join(root, filepath.FromSlash(tt.want)); got != want {
+				t.Errorf("regexPath() = %q, 期望 %q", got, want)
+			}
+		})
+	}
+}
+
+// TestRegexNoMatchSkipsHash 不匹配正则规则的文件在计算哈希之前就被跳过
+func TestRegexNoMatchSkipsHash(t *testing.T) {
+	rr, err := RegexRule{Pattern: "^z", Replace: "y"}.Compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	process := map[string]func(context.Context, Task) Result{
+		"重命名": processRename,
+		"移动":  processMove,
+	}
+	for name, fn := range process {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "a.txt")
+			if err := os.WriteFile(path, []byte("a"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			res := fn(context.Background(), Task{Path: path, SrcRoot: dir, DestRoot: filepath.Join(dir, "out"), Regex: rr})
+			if !res.Skipped || res.Err != nil {
+				t.Fatalf("Skipped = %v, Err = %v, 期望跳过且没有错误", res.Skipped, res.Err)
+			}
+			if res.SrcHashes != nil {
+				t.Errorf("SrcHashes = %v, 期望不计算哈希", res.SrcHashes)
+			}
+			if _, err := os.Stat(path); err != nil {
+				t.Errorf("源文件应保持不变: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package fileutil
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy 定义某类错误的重试参数：第n次重试前等待 InitialDelay × Multiplier^(n-1)，
+// 不超过MaxDelay，再按Jitter随机缩短，避免多个Worker同时重试同一个存储设备
+type RetryPolicy struct {
+	MaxAttempts  int           // 最多执行次数（含首次执行，1表示不重试）
+	InitialDelay time.Duration // 第一次重试前的等待时间
+	Multiplier   float64       // 每次重试后等待时间的倍数（小于1时按1处理）
+	MaxDelay     time.Duration // 单次等待时间的上限（0表示不限）
+	Jitter       float64       // 随机缩短等待时间的最大比例（0~1，0表示不随机）
+}
+
+// DefaultRetryPolicy 默认重试参数：最多重试3次，等待2秒、4秒、8秒（随机缩短不超过20%）
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:  4,
+	InitialDelay: 2 * time.Second,
+	Multiplier:   2,
+	MaxDelay:     30 * time.Second,
+	Jitter:       0.2,
+}
+
+// Validate 检查重试参数是否有效
+func (p RetryPolicy) Validate() error {
+	switch {
+	case p.MaxAttempts < 1:
+		return fmt.Errorf("最多执行次数必须大于0")
+	case p.InitialDelay < 0 || p.MaxDelay < 0:
+		return fmt.Errorf("重试等待时间不能为负数")
+	case p.Multiplier < 0:
+		return fmt.Errorf("重试等待时间倍数不能为负数")
+	case p.Jitter < 0 || p.Jitter > 1:
+		return fmt.Errorf("随机比例必须在0到1之间")
+	}
+	return nil
+}
+
+// Delay 返回第retry次重试（从1开始）前的等待时间，random为[0,1)的随机数
+func (p RetryPolicy) Delay(retry int, random float64) time.Duration {
+	multiplier := math.Max(p.Multiplier, 1)
+	delay := float64(p.InitialDelay) * math.Pow(multiplier, float64(retry-1))
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	delay *= 1 - p.Jitter*random
+	return time.Duration(delay)
+}
+
+// RetryBudget 整个批处理共用的重试预算，用尽后所有失败都不再重试，
+// 避免不稳定的网络存储让批处理无限拖延（字段为0表示不限）
+type RetryBudget struct {
+	MaxRetries int           // 所有文件合计的最多重试次数
+	MaxWait    time.Duration // 所有文件合计的最长重试等待时间
+}
+
+// DefaultRetryBudget 默认重试预算：不限次数，合计等待不超过10分钟
+var DefaultRetryBudget = RetryBudget{MaxWait: 10 * time.Minute}
+
+// RetryAttempt 记录一次失败的执行
+type RetryAttempt struct {
+	Err   error         // 本次执行的错误
+	Type  ErrorType     // 错误类型
+	Delay time.Duration // 重试前等待的时间（未重试时为0）
+}
+
+// SetRetryPolicy 设置特定错误类型的重试参数
+func (h *ErrorHandler) SetRetryPolicy(errorType ErrorType, policy RetryPolicy) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.retries[errorType] = policy
+}
+
+// RetryPolicy 返回特定错误类型的重试参数（未设置的类型使用默认参数）
+func (h *ErrorHandler) RetryPolicy(errorType ErrorType) RetryPolicy {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.retryPolicy(errorType)
+}
+
+// retryPolicy 返回特定错误类型的重试参数（调用方持有h.mu）
+func (h *ErrorHandler) retryPolicy(errorType ErrorType) RetryPolicy {
+	if policy, ok := h.retries[errorType]; ok {
+		return policy
+	}
+	return DefaultRetryPolicy
+}
+
+// SetRetryBudget 设置整个批处理共用的重试预算
+func (h *ErrorHandler) SetRetryBudget(budget RetryBudget) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.budget = budget
+}
+
+// RetryStats 返回已使用的重试次数、重试等待时间，以及重试预算是否已用尽
+func (h *ErrorHandler) RetryStats() (retries int, wait time.Duration, exhausted bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.spentRetries, h.spentWait, h.exhausted
+}
+
+// nextRetry 判断已重试retried次的任务能否再次重试，能重试时返回等待时间并从预算中扣除
+func (h *ErrorHandler) nextRetry(errorInfo ErrorInfo, retried int) (time.Duration, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	policy := h.retryPolicy(errorInfo.Type)
+	if retried+1 >= policy.MaxAttempts || h.exhausted {
+		return 0, false
+	}
+	delay := policy.Delay(retried+1, rand.Float64())
+	if (h.budget.MaxRetries > 0 && h.spentRetries >= h.budget.MaxRetries) ||
+		(h.budget.MaxWait > 0 && h.spentWait+delay > h.budget.MaxWait) {
+		h.exhausted = true
+		return 0, false
+	}
+	h.spentRetries++
+	h.spentWait += delay
+	return delay, true
+}
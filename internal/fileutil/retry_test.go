@@ -0,0 +1,173 @@
+package fileutil
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRetryPolicyDelay 等待时间按倍数增长，不超过上限，随机缩短不超过Jitter比例
+func TestRetryPolicyDelay(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		retry  int
+		random float64
+		want   time.Duration
+	}{
+		{"第一次重试", DefaultRetryPolicy, 1, 0, 2 * time.Second},
+		{"第二次重试", DefaultRetryPolicy, 2, 0, 4 * time.Second},
+		{"第三次重试", DefaultRetryPolicy, 3, 0, 8 * time.Second},
+		{"达到上限", DefaultRetryPolicy, 10, 0, 30 * time.Second},
+		{"随机缩短", DefaultRetryPolicy, 2, 0.5, 3600 * time.Millisecond},
+		{"上限后随机缩短", DefaultRetryPolicy, 10, 0.5, 27 * time.Second},
+		{"倍数小于1按1处理", RetryPolicy{InitialDelay: time.Second, Multiplier: 0.5}, 3, 0, time.Second},
+		{"上限为0表示不限", RetryPolicy{InitialDelay: time.Second, Multiplier: 10}, 4, 0, 1000 * time.Second},
+		{"完全随机", RetryPolicy{InitialDelay: time.Second, Multiplier: 1, Jitter: 1}, 1, 0.75, 250 * time.Millisecond},
+		{"不等待", RetryPolicy{Multiplier: 2}, 3, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Delay(tt.retry, tt.random); got != tt.want {
+				t.Errorf("Delay(%d, %v) = %v, 期望 %v", tt.retry, tt.random, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRetryPolicyValidate 执行次数至少为1，等待时间和倍数不能为负数，随机比例在0到1之间
+func TestRetryPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		wantErr string
+	}{
+		{"默认参数", DefaultRetryPolicy, ""},
+		{"不重试", RetryPolicy{MaxAttempts: 1}, ""},
+		{"执行次数为0", RetryPolicy{}, "最多执行次数"},
+		{"等待时间为负数", RetryPolicy{MaxAttempts: 2, InitialDelay: -time.Second}, "等待时间不能为负数"},
+		{"上限为负数", RetryPolicy{MaxAttempts: 2, MaxDelay: -time.Second}, "等待时间不能为负数"},
+		{"倍数为负数", RetryPolicy{MaxAttempts: 2, Multiplier: -1}, "倍数不能为负数"},
+		{"随机比例超过1", RetryPolicy{MaxAttempts: 2, Jitter: 1.5}, "随机比例"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() 返回错误: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() 错误 = %v, 期望包含 %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestNextRetry 达到最多执行次数时不再重试该任务；重试预算用尽后所有任务都不再重试
+func TestNextRetry(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, InitialDelay: 2 * time.Second, Multiplier: 2}
+	tests := []struct {
+		name      string
+		budget    RetryBudget
+		retried   []int  // 依次调用nextRetry时任务已重试的次数
+		want      []bool // 每次调用是否允许重试
+		retries   int
+		wait      time.Duration
+		exhausted bool
+	}{
+		{
+			name:    "不限预算时按执行次数",
+			retried: []int{0, 1, 2, 0},
+			want:    []bool{true, true, false, true},
+			retries: 3,
+			wait:    8 * time.Second,
+		},
+		{
+			name:      "重试次数用尽",
+			budget:    RetryBudget{MaxRetries: 2},
+			retried:   []int{0, 0, 0, 1},
+			want:      []bool{true, true, false, false},
+			retries:   2,
+			wait:      4 * time.Second,
+			exhausted: true,
+		},
+		{
+			name:      "等待时间用尽",
+			budget:    RetryBudget{MaxWait: 5 * time.Second},
+			retried:   []int{0, 1, 0},
+			want:      []bool{true, false, false},
+			retries:   1,
+			wait:      2 * time.Second,
+			exhausted: true,
+		},
+		{
+			name:    "等待时间刚好用完",
+			budget:  RetryBudget{MaxWait: 6 * time.Second},
+			retried: []int{0, 1},
+			want:    []bool{true, true},
+			retries: 2,
+			wait:    6 * time.Second,
+		},
+		{
+			name:    "达到执行次数不消耗预算",
+			budget:  RetryBudget{MaxRetries: 1},
+			retried: []int{2, 2, 0},
+			want:    []bool{false, false, true},
+			retries: 1,
+			wait:    2 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewErrorHandler()
+			h.SetRetryPolicy(ErrorIORead, policy)
+			h.SetRetryBudget(tt.budget)
+			info := ErrorInfo{Type: ErrorIORead}
+			for i, retried := range tt.retried {
+				delay, ok := h.nextRetry(info, retried)
+				if ok != tt.want[i] {
+					t.Fatalf("第%d次 nextRetry(已重试%d次) = %v, 期望 %v", i+1, retried, ok, tt.want[i])
+				}
+				if want := policy.Delay(retried+1, 0); ok && delay != want {
+					t.Errorf("第%d次等待时间 = %v, 期望 %v", i+1, delay, want)
+				}
+			}
+			retries, wait, exhausted := h.RetryStats()
+			if retries != tt.retries || wait != tt.wait || exhausted != tt.exhausted {
+				t.Errorf("RetryStats() = (%d, %v, %v), 期望 (%d, %v, %v)", retries, wait, exhausted, tt.retries, tt.wait, tt.exhausted)
+			}
+
+			h.Reset()
+			if retries, wait, exhausted := h.RetryStats(); retries != 0 || wait != 0 || exhausted {
+				t.Errorf("Reset() 后 RetryStats() = (%d, %v, %v), 期望清零", retries, wait, exhausted)
+			}
+		})
+	}
+}
+
+// TestSetMaxRetries 统一设置的重试次数和等待时间作用于所有错误类型，保留其余重试参数
+func TestSetMaxRetries(t *testing.T) {
+	h := NewErrorHandler()
+	h.SetMaxRetries(5)
+	h.SetRetryInterval(time.Second)
+	for _, et := range ErrorTypes() {
+		got := h.RetryPolicy(et)
+		want := DefaultRetryPolicy
+		want.MaxAttempts = 6
+		want.InitialDelay = time.Second
+		if got != want {
+			t.Errorf("RetryPolicy(%s) = %+v, 期望 %+v", et.Label(), got, want)
+		}
+	}
+
+	h.SetMaxRetries(-1)
+	if got := h.RetryPolicy(ErrorIORead).MaxAttempts; got != 1 {
+		t.Errorf("重试次数为负数时 MaxAttempts = %d, 期望 1", got)
+	}
+}
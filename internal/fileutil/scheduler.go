@@ -0,0 +1,663 @@
+package fileutil
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// SchedulerConfig 定义批处理调度配置
+type SchedulerConfig struct {
+	SrcRoot      string         // 源目录根路径
+	DestRoot     string         // 目标目录根路径
+	Prefix       string         // 重命名前缀
+	Suffix       string         // 重命名后缀
+	Template     string         // 重命名模板（text/template语法，设置后替代前缀/后缀）
+	Regex        *RegexRule     // 正则查找替换的重命名规则（可与前缀/后缀同时使用，不匹配的文件被跳过）
+	Transforms   []string       // 文件名变换链（按顺序执行，如 nfc、lower、truncate:100）
+	Numbering    *Numbering     // 序号设置：按前缀+序号+后缀重命名（与模板同时使用时只决定Seq的分配）
+	Mode         string         // 操作模式（已注册的Operator名称）
+	Workers      int            // 并发Worker数
+	ErrorHandler *ErrorHandler  // 错误处理器：各类错误的策略、重试参数和重试预算（为空时使用默认处理器）
+	Algorithms   []string       // 哈希算法列表（为空时使用MD5）
+	StrictVerify bool           // 复制后重新读取目标文件做严格校验
+	ManifestPath string         // 计算哈希模式下输出的校验清单路径（为空时不输出）
+	ExcludePaths []string       // 扫描时排除的文件路径
+	Files        []string       // 指定待处理的文件列表（不为空时不扫描SrcRoot）
+	Filter       *Filter        // 扫描源目录时的筛选条件（为空时处理所有文件）
+	Compare      string         // 同步模式判断文件是否变化的方式（size_mtime/hash）
+	Delete       bool           // 同步模式下删除目标目录中源目录已不存在的文件
+	JournalPath  string         // 断点文件路径（为空时不记录）
+	Resume       bool           // 断点续作：跳过断点文件中已完成的任务，重新处理失败的任务
+	UndoPath     string         // 重命名/移动等破坏性操作的撤销日志路径（为空时不记录）
+	Plan         *Plan          // 按执行计划处理：只处理计划中的文件，输出路径与计划一致
+	OnConflict   ConflictPolicy // 目标已存在时的处理策略（为空时使用默认策略）
+	SpaceCheck   string         // 启动前检查目标磁盘空间的方式（fail/warn/off，为空时按fail；边扫描边处理时先遍历源目录统计大小，为off时不遍历）
+	SpaceMargin  int64          // 复制后每个目标文件系统至少保留的可用字节数
+	Preserve     Preserve       // 复制时保留的元数据（为空时保留权限和时间）
+}
+
+// Summary 定义批处理最终统计
+type Summary struct {
+	Total       int                     // 任务总数
+	Success     int                     // 成功数
+	Skipped     int                     // 跳过数
+	Failed      int                     // 失败数
+	Canceled    int                     // 因中止或取消而未完成的任务数
+	TempCleaned int                     // 启动时清理的中断遗留临时文件数
+	Unchanged   int                     // 同步模式下未变化而跳过复制的文件数（计入Success）
+	Deleted     int                     // 同步模式下从目标目录删除的文件数
+	DeleteErr   error                   // 删除目标目录多余文件失败（或未执行）的原因
+	Resumed     int                     // 断点续作时因上次已完成而跳过的文件数（不计入Total）
+	Journal     string                  // 断点文件路径
+	JournalErr  error                   // 写出断点文件失败的原因
+	UndoLog     string                  // 撤销日志路径（有可撤销的记录时）
+	UndoErr     error                   // 写入撤销日志失败的原因
+	Retries     int                     // 所有文件合计的重试次数
+	RetryWait   time.Duration           // 所有文件合计的重试等待时间
+	BudgetSpent bool                    // 重试预算已用尽，之后的失败不再重试
+	Trips       int                     // 同类错误集中出现或系统性故障导致的熔断次数
+	Conflicts   map[ConflictOutcome]int // 目标已存在时各处理结果的文件数
+	Stranded    []string                // 两阶段重命名中未能移回原路径、仍在暂存路径的文件
+	SpaceShort  []SpaceShortage         // 启动前预检发现空间不足的目标文件系统（SpaceCheck为warn时）
+	MetaFailed  map[MetaAttr]int        // 复制成功但未能保留各项元数据的文件数
+	Filtered    map[FilterReason]int    // 扫描时各筛选条件排除的文件数（整个被排除的目录计为一项）
+	Manifest    string                  // 已写出的校验清单路径
+	ManifestErr error                   // 写出校验清单失败的原因
+	Aborted     bool                    // 是否因错误策略被中止
+	Interrupted bool                    // 是否被调用方取消（用户中止或收到退出信号）
+	Duration    time.Duration           // 总耗时
+}
+
+// Scheduler 基于Worker Pool的批处理调度器
+type Scheduler struct {
+	cfg       SchedulerConfig
+	handler   *ErrorHandler
+	scanned   []string
+	scanErrs  int
+	scanErr   error
+	scanning  bool
+	filter    *ScanFilter
+	journal   *Journal
+	undo      *UndoLog
+	targets   map[string]string
+	template  *RenameTemplate
+	regex     *RenameRegex
+	transform *TransformChain
+	seq       map[string]int
+	seqWidth  int
+	staged    map[string]string
+	results   chan Result
+	done      chan struct{}
+	startTime time.Time
+	cancel    context.CancelFunc
+
+	mu       sync.Mutex
+	aborted  bool
+	summary  Summary
+	manifest []ManifestEntry
+}
+
+// NewScheduler 创建批处理调度器
+func NewScheduler(cfg SchedulerConfig) *Scheduler {
+	if cfg.Workers < 1 {
+		cfg.Workers = 1
+	}
+	handler := cfg.ErrorHandler
+	if handler == nil {
+		handler = NewErrorHandler()
+	}
+	return &Scheduler{
+		cfg:     cfg,
+		handler: handler,
+		done:    make(chan struct{}),
+	}
+}
+
+// Start 扫描源目录并启动Worker Pool，返回结果通道
+// 结果通道在所有任务完成（或中止）后关闭，之后可通过Wait获取最终统计；
+// ctx取消时正在进行的复制和哈希计算会被中断，效果与Abort相同
+func (s *Scheduler) Start(ctx context.Context) (<-chan Result, error) {
+	if s.cfg.SrcRoot == "" && len(s.cfg.Files) == 0 {
+		return nil, fmt.Errorf("未指定源目录")
+	}
+	if s.results != nil {
+		return nil, fmt.Errorf("调度器已启动")
+	}
+
+	// 校验操作参数
+	op, ok := LookupOperator(s.cfg.Mode)
+	if !ok {
+		return nil, fmt.Errorf("不支持的操作模式: %s", s.cfg.Mode)
+	}
+	if err := s.prepare(); err != nil {
+		return nil, err
+	}
+	if err := op.Validate(s.newTask("")); err != nil {
+		return nil, err
+	}
+	if s.cfg.ManifestPath != "" && s.cfg.Mode != "md5" {
+		return nil, fmt.Errorf("校验清单仅支持计算哈希模式")
+	}
+	if s.cfg.Plan != nil {
+		if s.cfg.Plan.Mode != s.cfg.Mode {
+			return nil, fmt.Errorf("执行计划的操作模式（%s）与本次任务不一致", s.cfg.Plan.Mode)
+		}
+		if len(s.cfg.Files) > 0 {
+			return nil, fmt.Errorf("不能同时指定执行计划和文件列表")
+		}
+		s.targets = make(map[string]string, len(s.cfg.Plan.Items))
+		for _, item := range s.cfg.Plan.Items {
+			s.cfg.Files = append(s.cfg.Files, item.Source)
+			s.targets[item.Source] = item.Target
+		}
+	}
+	if s.cfg.Delete && (s.cfg.Mode != "sync" || len(s.cfg.Files) > 0) {
+		return nil, fmt.Errorf("删除多余文件仅支持扫描源目录的同步模式")
+	}
+
+	s.startTime = time.Now()
+
+	if s.cfg.JournalPath != "" {
+		journal, err := openJournal(s.cfg.JournalPath, s.cfg, s.cfg.Resume)
+		if err != nil {
+			return nil, err
+		}
+		s.journal = journal
+		s.summary.Journal = journal.Path()
+	}
+	if s.cfg.UndoPath != "" && op.Info().Destructive {
+		undo, err := createUndoLog(s.cfg.UndoPath, s.cfg)
+		if err != nil {
+			return nil, err
+		}
+		s.undo = undo
+	}
+
+	// 清理上次中断遗留的临时文件（写入发生在目标目录，原地操作时在源目录）
+	writeRoot := s.cfg.DestRoot
+	if writeRoot == "" {
+		writeRoot = s.cfg.SrcRoot
+	}
+	if !op.Info().ReadOnly && writeRoot != "" {
+		cleaned, err := CleanStaleTempFiles(writeRoot)
+		if err != nil {
+			return nil, fmt.Errorf("清理临时文件失败: %w", err)
+		}
+		s.summary.TempCleaned = cleaned
+	}
+
+	ctx, s.cancel = context.WithCancel(ctx)
+	paths, err := s.feed(ctx, op)
+	if err != nil {
+		s.cancel()
+		return nil, err
+	}
+
+	tasks := make(chan Task, s.cfg.Workers)
+	raw := make(chan Result, s.cfg.Workers)
+	s.results = make(chan Result, streamQueueSize)
+
+	// 启动Worker Pool
+	var wg sync.WaitGroup
+	for i := 0; i < s.cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range tasks {
+				// 检查是否中止
+				if ctx.Err() != nil {
+					return
+				}
+				raw <- ProcessFileWithRetryContext(ctx, t, s.handler)
+			}
+		}()
+	}
+
+	// 分发任务（任务通道有界，Worker忙时阻塞扫描）
+	go func() {
+		defer close(tasks)
+		for f := range paths {
+			select {
+			case tasks <- s.newTask(f):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// 等待Worker完成并关闭结果通道
+	go func() {
+		wg.Wait()
+		close(raw)
+	}()
+
+	// 汇总结果并转发给调用方
+	go s.collect(ctx, raw)
+
+	return s.results, nil
+}
+
+// Total 返回任务总数（Start成功后有效）；边扫描边处理时为目前已发现的文件数，随扫描增加
+func (s *Scheduler) Total() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.summary.Total
+}
+
+// Scanning 返回是否仍在扫描源目录（此时Total只是目前的估计值）
+func (s *Scheduler) Scanning() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.scanning
+}
+
+// feed 返回待处理文件的通道：可以边扫描边处理时由后台扫描流式提供，
+// 否则先得到完整的文件列表，分配序号、过滤已完成的文件并暂存互换的文件后依次提供
+func (s *Scheduler) feed(ctx context.Context, op Operator) (<-chan string, error) {
+	// 排除清单等工具自身写出的文件
+	exclude := map[string]bool{}
+	for _, p := range append([]string{s.cfg.ManifestPath}, s.cfg.ExcludePaths...) {
+		if p == "" {
+			continue
+		}
+		if abs, err := filepath.Abs(p); err == nil {
+			exclude[abs] = true
+		}
+	}
+	if s.streams(op) {
+		// 先遍历一遍源目录统计需要写入的大小，空间不足时拒绝启动（不检查空间时不遍历）
+		if err := s.checkSpace(ctx, op, s.walkPending(ctx, exclude)); err != nil {
+			return nil, err
+		}
+		s.scanning = true
+		return s.streamFiles(ctx, exclude)
+	}
+
+	files := s.cfg.Files
+	if len(files) == 0 {
+		var err error
+		files, s.scanErrs, err = scanFiles(s.cfg.SrcRoot, exclude, s.filter)
+		if err != nil {
+			return nil, fmt.Errorf("扫描源目录失败: %w", err)
+		}
+		if s.filter != nil {
+			s.summary.Filtered = s.filter.Counts()
+		}
+	}
+	if len(files) == 0 && len(s.summary.Filtered) > 0 {
+		return nil, fmt.Errorf("源目录中没有符合筛选条件的文件（已排除: %s）", FormatFiltered(s.summary.Filtered))
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("源目录中没有找到文件")
+	}
+	s.scanned = files
+	s.numberFiles(files)
+	if s.journal != nil && s.cfg.Resume {
+		// 跳过上次已完成的任务（可能全部完成，此时没有任务需要执行）
+		var todo []string
+		for _, f := range files {
+			if s.resumed(ctx, f) {
+				s.summary.Resumed++
+				continue
+			}
+			todo = append(todo, f)
+		}
+		files = todo
+	}
+	s.summary.Total = len(files)
+	if err := s.checkSpace(ctx, op, func(fn func(path string) error) error {
+		for _, f := range files {
+			if err := fn(f); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if err := s.stageSwaps(ctx, op, files); err != nil {
+		return nil, err
+	}
+
+	paths := make(chan string, streamQueueSize)
+	go func() {
+		defer close(paths)
+		for _, f := range files {
+			select {
+			case paths <- f:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return paths, nil
+}
+
+// Abort 中止批处理：Worker不再领取新任务，正在进行的复制和哈希计算被中断
+func (s *Scheduler) Abort() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+}
+
+// Aborted 返回批处理是否已因错误策略被中止
+func (s *Scheduler) Aborted() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.aborted
+}
+
+// Snapshot 返回当前统计（批处理进行中也可调用）
+func (s *Scheduler) Snapshot() Summary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	summary := s.summary
+	if !s.startTime.IsZero() && summary.Duration == 0 {
+		summary.Duration = time.Since(s.startTime)
+	}
+	return summary
+}
+
+// Wait 等待批处理结束并返回最终统计
+func (s *Scheduler) Wait() Summary {
+	<-s.done
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.summary
+}
+
+// Flush 立即写出断点文件中已记录的结果。等待任务退出超时、准备强制退出时调用，
+// 使已完成的任务在续作时能被跳过（正常结束时由调度器自动写出）
+func (s *Scheduler) Flush() error {
+	if s.journal == nil {
+		return nil
+	}
+	return s.journal.Flush()
+}
+
+// abortByPolicy 因错误策略中止批处理
+func (s *Scheduler) abortByPolicy() {
+	s.mu.Lock()
+	s.aborted = true
+	s.mu.Unlock()
+	s.cancel()
+}
+
+// prepare 编译扫描筛选条件、重命名模板、正则规则和文件名变换链，校验序号设置
+func (s *Scheduler) prepare() error {
+	if err := validateSpaceCheck(s.cfg.SpaceCheck); err != nil {
+		return err
+	}
+	if s.cfg.SpaceMargin < 0 {
+		return fmt.Errorf("磁盘空间安全余量不能为负数")
+	}
+	if err := s.cfg.Preserve.Validate(); err != nil {
+		return err
+	}
+	if s.cfg.Filter != nil && !s.cfg.Filter.IsZero() {
+		if s.cfg.Delete {
+			return fmt.Errorf("删除多余文件不能与扫描筛选同时使用（被筛选排除的文件会被误删）")
+		}
+		filter, err := s.cfg.Filter.Compile(s.cfg.SrcRoot)
+		if err != nil {
+			return err
+		}
+		s.filter = filter
+	}
+	if s.cfg.Numbering != nil {
+		if err := s.cfg.Numbering.Validate(); err != nil {
+			return err
+		}
+		if s.cfg.Regex != nil {
+			return fmt.Errorf("序号重命名不能与正则替换同时使用")
+		}
+	}
+	if s.cfg.Template != "" {
+		tmpl, err := ParseRenameTemplate(s.cfg.Template)
+		if err != nil {
+			return err
+		}
+		s.template = tmpl
+	}
+	if s.cfg.Regex != nil {
+		re, err := s.cfg.Regex.Compile()
+		if err != nil {
+			return err
+		}
+		s.regex = re
+	}
+	chain, err := ParseTransforms(s.cfg.Transforms)
+	if err != nil {
+		return err
+	}
+	s.transform = chain
+	return nil
+}
+
+// numberFiles 按序号设置排序文件列表并分配序号（未设置时按文件名排序、从1开始），
+// 供序号重命名和重命名模板的Seq字段使用；序号在派发任务前确定，与Worker完成顺序无关
+func (s *Scheduler) numberFiles(files []string) {
+	if s.template == nil && s.cfg.Numbering == nil {
+		return
+	}
+	n := DefaultNumbering()
+	if s.cfg.Numbering != nil {
+		n = *s.cfg.Numbering
+	}
+	s.seq = make(map[string]int, len(files))
+	for i, f := range sortFiles(s.cfg.SrcRoot, files, n.Sort) {
+		s.seq[f] = n.Start + i*n.Step
+	}
+	s.seqWidth = seqWidth(n, len(files))
+}
+
+// newTask 根据调度配置生成单个文件任务
+func (s *Scheduler) newTask(path string) Task {
+	return Task{
+		Path:     path,
+		SrcRoot:  s.cfg.SrcRoot,
+		DestRoot: s.cfg.DestRoot,
+		Prefix:   s.cfg.Prefix,
+		Suffix:   s.cfg.Suffix,
+		Mode:     s.cfg.Mode,
+
+		Algorithms:   s.cfg.Algorithms,
+		StrictVerify: s.cfg.StrictVerify,
+		Compare:      s.cfg.Compare,
+		Target:       s.targets[path],
+		OnConflict:   s.cfg.OnConflict,
+		SpaceCheck:   s.cfg.SpaceCheck != SpaceCheckOff,
+		SpaceMargin:  s.cfg.SpaceMargin,
+		Preserve:     s.cfg.Preserve,
+		Template:     s.template,
+		Regex:        s.regex,
+		Transforms:   s.transform,
+		Seq:          s.seq[path],
+		SeqWidth:     s.seqWidth,
+		Numbered:     s.cfg.Numbering != nil && s.template == nil,
+		Staged:       s.staged[path],
+	}
+}
+
+// collect 统计结果，遇到需要终止的错误时中止整个批处理
+func (s *Scheduler) collect(ctx context.Context, raw <-chan Result) {
+	defer func() {
+		s.writeManifest(ctx.Err() == nil)
+		s.prune(ctx, ctx.Err() == nil)
+
+		s.mu.Lock()
+		s.restoreStaged()
+		if s.journal != nil {
+			if err := s.journal.Flush(); err != nil && s.summary.JournalErr == nil {
+				s.summary.JournalErr = err
+			}
+		}
+		if s.undo != nil {
+			if err := s.undo.Close(); err != nil && s.summary.UndoErr == nil {
+				s.summary.UndoErr = err
+			}
+			if s.undo.Count() > 0 {
+				s.summary.UndoLog = s.undo.Path()
+			}
+		}
+		_, _, s.summary.BudgetSpent = s.handler.RetryStats()
+		s.summary.Trips = s.handler.Trips()
+		s.summary.Duration = time.Since(s.startTime)
+		s.summary.Aborted = s.aborted
+		s.summary.Interrupted = !s.aborted && ctx.Err() != nil
+		s.summary.Canceled = s.summary.Total - s.summary.Success - s.summary.Skipped - s.summary.Failed
+		s.mu.Unlock()
+		s.cancel()
+		close(s.results)
+		close(s.done)
+	}()
+
+	for res := range raw {
+		// 中止后丢弃失败的结果（被中断的任务计入Canceled），已完成的任务照常统计
+		if ctx.Err() != nil && res.Err != nil {
+			s.mu.Lock()
+			s.finishStaged(res)
+			s.mu.Unlock()
+			continue
+		}
+
+		s.mu.Lock()
+		if res.Err == nil && !res.Skipped {
+			s.summary.Success++
+			if res.Unchanged {
+				s.summary.Unchanged++
+			}
+			s.addManifestEntry(res)
+		} else if res.Skipped {
+			s.summary.Skipped++
+		} else {
+			s.summary.Failed++
+		}
+		s.summary.Retries += res.Retried
+		for _, a := range res.Attempts {
+			s.summary.RetryWait += a.Delay
+		}
+		if res.Conflict != ConflictNone {
+			if s.summary.Conflicts == nil {
+				s.summary.Conflicts = map[ConflictOutcome]int{}
+			}
+			s.summary.Conflicts[res.Conflict]++
+		}
+		for _, f := range res.MetaErrs {
+			if s.summary.MetaFailed == nil {
+				s.summary.MetaFailed = map[MetaAttr]int{}
+			}
+			s.summary.MetaFailed[f.Attr]++
+		}
+		if s.journal != nil {
+			if err := s.journal.record(res); err != nil && s.summary.JournalErr == nil {
+				s.summary.JournalErr = err
+			}
+		}
+		if s.undo != nil && res.Err == nil {
+			if err := s.undo.record(res); err != nil && s.summary.UndoErr == nil {
+				s.summary.UndoErr = err
+			}
+		}
+		s.finishStaged(res)
+		s.mu.Unlock()
+
+		s.results <- res
+
+		// 检查是否需要中止
+		if res.Err != nil && !res.Skipped && s.handler.aborting() {
+			s.abortByPolicy()
+		}
+	}
+}
+
+// addManifestEntry 记录校验清单条目（调用方持有s.mu）
+func (s *Scheduler) addManifestEntry(res Result) {
+	if s.cfg.ManifestPath == "" {
+		return
+	}
+	s.addManifestSum(res.OldName, res.SrcHashes[algorithmsOf(s.cfg.Algorithms)[0]])
+}
+
+// addManifestSum 按源文件路径记录校验清单条目（调用方持有s.mu）
+func (s *Scheduler) addManifestSum(path, sum string) {
+	rel, err := manifestRelPath(s.cfg.SrcRoot, path)
+	if err != nil {
+		return
+	}
+	s.manifest = append(s.manifest, ManifestEntry{Sum: sum, Path: rel})
+}
+
+// resumed 断点续作时判断文件是否已在上次完成。需要写出校验清单时，已完成的文件使用断点文件中记录的哈希计入清单，
+// 记录的哈希算法与清单不同（或没有记录哈希）时重新处理该文件，保证清单完整
+func (s *Scheduler) resumed(ctx context.Context, path string) bool {
+	if s.journal == nil || !s.cfg.Resume {
+		return false
+	}
+	if s.cfg.ManifestPath == "" {
+		return s.journal.completed(ctx, path)
+	}
+	if s.journal.Algorithm != algorithmsOf(s.cfg.Algorithms)[0] {
+		return false
+	}
+	entry := s.journal.lookup(ctx, path)
+	if entry == nil || entry.Hash == "" {
+		return false
+	}
+	s.mu.Lock()
+	s.addManifestSum(path, entry.Hash)
+	s.mu.Unlock()
+	return true
+}
+
+// writeManifest 批处理结束后写出校验清单，批处理未完成时不写出，避免不完整的清单被当作完整清单使用
+func (s *Scheduler) writeManifest(complete bool) {
+	if s.cfg.ManifestPath == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !complete {
+		s.summary.ManifestErr = fmt.Errorf("批处理未完成，未写出校验清单")
+		return
+	}
+	if err := WriteManifestFile(s.cfg.ManifestPath, s.manifest); err != nil {
+		s.summary.ManifestErr = err
+		return
+	}
+	s.summary.Manifest = s.cfg.ManifestPath
+}
+
+// prune 同步模式下删除目标目录中源目录已不存在的文件
+// 批处理未完成或源目录有无法读取的子目录时不删除，避免误删
+func (s *Scheduler) prune(ctx context.Context, complete bool) {
+	if !s.cfg.Delete {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case !complete:
+		s.summary.DeleteErr = fmt.Errorf("批处理未完成，未删除目标目录中的多余文件")
+		return
+	case s.scanErrs > 0:
+		s.summary.DeleteErr = fmt.Errorf("源目录中有%d个路径无法读取，未删除目标目录中的多余文件", s.scanErrs)
+		return
+	}
+
+	expected := make(map[string]bool, len(s.scanned))
+	for _, f := range s.scanned {
+		newPath, err := targetPath(ctx, s.newTask(f), false)
+		if err != nil {
+			s.summary.DeleteErr = fmt.Errorf("生成目标路径失败: %w", err)
+			return
+		}
+		if abs, err := filepath.Abs(newPath); err == nil {
+			expected[abs] = true
+		}
+	}
+	s.summary.Deleted, s.summary.DeleteErr = pruneDestination(s.cfg.SrcRoot, s.cfg.DestRoot, expected)
+}
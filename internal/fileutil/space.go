@@ -0,0 +1,300 @@
+package fileutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 启动批处理前检查目标磁盘空间的方式
+const (
+	SpaceCheckFail = "fail" // 空间不足时拒绝启动（默认）
+	SpaceCheckWarn = "warn" // 空间不足时只记录在统计中，照常启动
+	SpaceCheckOff  = "off"  // 不检查（复制每个文件前也不检查）
+)
+
+// DefaultSpaceMargin 默认的安全余量：复制后每个目标文件系统至少保留的可用字节数
+const DefaultSpaceMargin int64 = 64 << 20
+
+// errSpaceUnsupported 当前平台无法获取磁盘可用空间
+var errSpaceUnsupported = errors.New("当前平台不支持获取磁盘可用空间")
+
+// SpaceCheckModes 返回所有支持的磁盘空间检查方式
+func SpaceCheckModes() []string {
+	return []string{SpaceCheckFail, SpaceCheckWarn, SpaceCheckOff}
+}
+
+// validateSpaceCheck 校验磁盘空间检查方式
+func validateSpaceCheck(mode string) error {
+	switch mode {
+	case "", SpaceCheckFail, SpaceCheckWarn, SpaceCheckOff:
+		return nil
+	}
+	return fmt.Errorf("不支持的磁盘空间检查方式: %s（可选：%s）", mode, strings.Join(SpaceCheckModes(), "/"))
+}
+
+// SpaceShortage 目标文件系统的可用空间不足以完成批处理
+type SpaceShortage struct {
+	Path string // 目标文件系统上的目录
+	Need int64  // 需要的字节数（含安全余量）
+	Free int64  // 可用字节数
+}
+
+// String 返回空间不足的说明
+func (s SpaceShortage) String() string {
+	return fmt.Sprintf("%s 需要%s，可用%s", s.Path, FormatBytes(s.Need), FormatBytes(s.Free))
+}
+
+// nearestDir 返回path本身或最近的已存在的上级目录（目标目录可能尚未创建）
+func nearestDir(path string) string {
+	dir := absPath(path)
+	for {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// spaceReservations 各文件系统上已被进行中的复制预留的字节数，
+// 使并发的Worker不会在同一时刻都通过空间检查
+var spaceReservations = struct {
+	sync.Mutex
+	bytes map[string]int64
+}{bytes: map[string]int64{}}
+
+// spaceReservation 一次复制在目标文件系统上预留的字节数。已写入目标文件的字节
+// 已经从可用空间中扣除，随写入逐步释放对应的预留，避免同一部分空间被重复计算
+type spaceReservation struct {
+	dev  string
+	left int64 // 尚未写入的预留字节数
+}
+
+// Write 记录写入目标文件的字节数并释放相应的预留，供复制时与目标文件一起写入
+func (r *spaceReservation) Write(p []byte) (int, error) {
+	r.consume(int64(len(p)))
+	return len(p), nil
+}
+
+// consume 释放最多n字节的预留
+func (r *spaceReservation) consume(n int64) {
+	if r == nil {
+		return
+	}
+	spaceReservations.Lock()
+	defer spaceReservations.Unlock()
+	n = min(n, r.left)
+	if n <= 0 {
+		return
+	}
+	r.left -= n
+	if spaceReservations.bytes[r.dev] -= n; spaceReservations.bytes[r.dev] <= 0 {
+		delete(spaceReservations.bytes, r.dev)
+	}
+}
+
+// release 复制结束后释放剩余的预留（可以对nil调用）
+func (r *spaceReservation) release() {
+	r.consume(math.MaxInt64)
+}
+
+// checkDiskSpace 复制前检查目标所在文件系统的可用空间：扣除其他任务尚未写入的预留字节数后，
+// 仍需容纳源文件和安全余量margin。通过时预留源文件大小，复制过程中写入返回的预留逐步释放，
+// 复制结束后调用release释放剩余部分；无法获取可用空间时不检查，返回nil。
+// 空间不足的错误包装ENOSPC，按磁盘空间不足处理
+func checkDiskSpace(srcPath, dstPath string, margin int64) (*spaceReservation, error) {
+	info, err := os.Stat(srcPath)
+	if err != nil {
+		// 源文件的错误由复制过程报告
+		return nil, nil
+	}
+	size := info.Size()
+
+	dir := nearestDir(filepath.Dir(dstPath))
+	dev, err := deviceID(dir)
+	if err != nil {
+		return nil, nil
+	}
+
+	spaceReservations.Lock()
+	defer spaceReservations.Unlock()
+	free, err := getFreeDiskSpace(dir)
+	if err != nil {
+		return nil, nil
+	}
+	reserved := spaceReservations.bytes[dev]
+	if available := int64(free) - reserved; available < size+margin {
+		return nil, fmt.Errorf("需要%s（含安全余量%s），可用%s（其他任务已预留%s）: %w",
+			FormatBytes(size+margin), FormatBytes(margin), FormatBytes(int64(free)), FormatBytes(reserved), errNoSpace)
+	}
+	if size == 0 {
+		return nil, nil
+	}
+	spaceReservations.bytes[dev] += size
+	return &spaceReservation{dev: dev, left: size}, nil
+}
+
+// reserveSpace 任务开启空间检查时检查并预留复制到dstPath所需的磁盘空间（未开启时返回nil）
+func reserveSpace(t Task, dstPath string) (*spaceReservation, error) {
+	if !t.SpaceCheck {
+		return nil, nil
+	}
+	return checkDiskSpace(t.Path, dstPath, t.SpaceMargin)
+}
+
+// spaceNeed 预检时某个目标文件系统需要写入的字节数
+type spaceNeed struct {
+	dir   string
+	bytes int64
+}
+
+// conflictNeed 目标文件已存在时估算需要写入的字节数：同步模式以及覆盖类策略按差额计算，
+// 源文件不比已有文件新时newer策略不写入，跳过和报错策略不写入，保留两者策略写入完整文件
+func conflictNeed(info OperatorInfo, policy ConflictPolicy, srcInfo, dstInfo os.FileInfo) int64 {
+	size := srcInfo.Size()
+	if info.Compares {
+		return max(size-dstInfo.Size(), 0)
+	}
+	switch policy {
+	case OnConflictSkip, OnConflictFail:
+		return 0
+	case OnConflictKeepBoth:
+		return size
+	case OnConflictNewer:
+		if !srcInfo.ModTime().After(dstInfo.ModTime()) {
+			return 0
+		}
+	}
+	return max(size-dstInfo.Size(), 0)
+}
+
+// spaceNeeds 按目标文件系统汇总需要写入的字节数。each遍历待处理的源文件；
+// 同一文件系统内的移动不需要额外空间，已存在的目标文件按冲突策略（同步模式下按差额）计算。
+// 无法确定目标所在的文件系统时返回errSpaceUnsupported
+func (s *Scheduler) spaceNeeds(ctx context.Context, op Operator, each func(fn func(path string) error) error) (map[string]*spaceNeed, error) {
+	info := op.Info()
+	policy := s.cfg.OnConflict
+	if policy == "" {
+		policy = DefaultConflictPolicy
+	}
+	planner, _ := op.(Planner)
+
+	needs := map[string]*spaceNeed{}
+	devices := map[string]string{} // 目录 -> 文件系统标识
+	device := func(dir string) (string, error) {
+		if dev, ok := devices[dir]; ok {
+			return dev, nil
+		}
+		dev, err := deviceID(nearestDir(dir))
+		if err != nil {
+			return "", err
+		}
+		devices[dir] = dev
+		return dev, nil
+	}
+
+	err := each(func(path string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		srcInfo, err := os.Stat(path)
+		if err != nil {
+			return nil
+		}
+		target := filepath.Join(s.cfg.DestRoot, filepath.Base(path))
+		if rel, err := filepath.Rel(s.cfg.SrcRoot, filepath.Dir(path)); err == nil && s.cfg.SrcRoot != "" && withinDir(path, s.cfg.SrcRoot) {
+			target = filepath.Join(s.cfg.DestRoot, rel, filepath.Base(path))
+		}
+		if planner != nil {
+			// 无法计算输出路径（如不匹配重命名规则）的文件不会被复制
+			if target, err = planner.Target(ctx, s.newTask(path)); err != nil || target == "" {
+				return nil
+			}
+		}
+		destDir := filepath.Dir(target)
+		dev, err := device(destDir)
+		if err != nil {
+			// 无法确定目标所在的文件系统时不做预检
+			return errSpaceUnsupported
+		}
+
+		size := srcInfo.Size()
+		if info.Destructive {
+			// 同一文件系统内的移动只是重命名
+			if srcDev, err := device(filepath.Dir(path)); err == nil && srcDev == dev {
+				return nil
+			}
+		}
+		if dstInfo, err := os.Stat(target); err == nil && !dstInfo.IsDir() {
+			size = conflictNeed(info, policy, srcInfo, dstInfo)
+		}
+		n := needs[dev]
+		if n == nil {
+			n = &spaceNeed{dir: nearestDir(destDir)}
+			needs[dev] = n
+		}
+		n.bytes += size
+		return nil
+	})
+	return needs, err
+}
+
+// preflightSpace 启动前按目标文件系统汇总需要写入的字节数（见spaceNeeds），
+// 与可用空间（扣除安全余量）比较。无法获取可用空间时不检查
+func (s *Scheduler) preflightSpace(ctx context.Context, op Operator, each func(fn func(path string) error) error) ([]SpaceShortage, error) {
+	info := op.Info()
+	if info.ReadOnly || !info.NeedsDest || s.cfg.DestRoot == "" || s.cfg.SpaceCheck == SpaceCheckOff {
+		return nil, nil
+	}
+
+	needs, err := s.spaceNeeds(ctx, op, each)
+	if errors.Is(err, errSpaceUnsupported) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var shortages []SpaceShortage
+	for _, n := range needs {
+		free, err := getFreeDiskSpace(n.dir)
+		if err != nil {
+			continue
+		}
+		if need := n.bytes + s.cfg.SpaceMargin; int64(free) < need {
+			shortages = append(shortages, SpaceShortage{Path: n.dir, Need: need, Free: int64(free)})
+		}
+	}
+	sort.Slice(shortages, func(i, j int) bool { return shortages[i].Path < shortages[j].Path })
+	return shortages, nil
+}
+
+// checkSpace 执行启动前的磁盘空间预检：不足时按SpaceCheck拒绝启动或记入统计
+func (s *Scheduler) checkSpace(ctx context.Context, op Operator, each func(fn func(path string) error) error) error {
+	shortages, err := s.preflightSpace(ctx, op, each)
+	if err != nil {
+		return fmt.Errorf("检查目标磁盘空间失败: %w", err)
+	}
+	if len(shortages) == 0 {
+		return nil
+	}
+	if s.cfg.SpaceCheck == SpaceCheckWarn {
+		s.summary.SpaceShort = shortages
+		return nil
+	}
+	var lines []string
+	for _, short := range shortages {
+		lines = append(lines, short.String())
+	}
+	return fmt.Errorf("目标磁盘空间不足（含安全余量%s）: %s", FormatBytes(s.cfg.SpaceMargin), strings.Join(lines, "；"))
+}
@@ -0,0 +1,13 @@
+//go:build !(linux || darwin || freebsd || windows)
+
+package fileutil
+
+// getFreeDiskSpace 当前平台无法获取磁盘可用空间，不做空间检查
+func getFreeDiskSpace(string) (uint64, error) {
+	return 0, errSpaceUnsupported
+}
+
+// deviceID 当前平台无法区分文件系统，不做空间检查
+func deviceID(string) (string, error) {
+	return "", errSpaceUnsupported
+}
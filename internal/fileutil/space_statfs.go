@@ -0,0 +1,26 @@
+//go:build linux || darwin || freebsd
+
+package fileutil
+
+import (
+	"strconv"
+	"syscall"
+)
+
+// getFreeDiskSpace 获取path所在文件系统中非特权用户可用的字节数
+func getFreeDiskSpace(path string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
+
+// deviceID 返回path所在文件系统的标识（同一文件系统上的路径标识相同）
+func deviceID(path string) (string, error) {
+	var st syscall.Stat_t
+	if err := syscall.Stat(path, &st); err != nil {
+		return "", err
+	}
+	return strconv.FormatUint(uint64(st.Dev), 10), nil
+}
@@ -0,0 +1,250 @@
+package fileutil
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// spaceDevice 返回目录所在的文件系统标识，当前平台不支持时跳过测试
+func spaceDevice(t *testing.T, dir string) string {
+	t.Helper()
+	dev, err := deviceID(dir)
+	if err != nil {
+		t.Skip(err)
+	}
+	return dev
+}
+
+// reservedBytes 返回文件系统上已预留的字节数
+func reservedBytes(dev string) int64 {
+	spaceReservations.Lock()
+	defer spaceReservations.Unlock()
+	return spaceReservations.bytes[dev]
+}
+
+// TestSpaceReservation 预留随写入逐步释放，写入超过预留时不会多释放，结束后释放剩余部分
+func TestSpaceReservation(t *testing.T) {
+	dir := t.TempDir()
+	dev := spaceDevice(t, dir)
+	src := filepath.Join(dir, "src")
+	if err := os.WriteFile(src, make([]byte, 3000), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(dir, "out", "dst")
+
+	r1, err := checkDiskSpace(src, dst, 0)
+	if err != nil || r1 == nil {
+		t.Fatalf("checkDiskSpace() = %v, %v", r1, err)
+	}
+	if got := reservedBytes(dev); got != 3000 {
+		t.Fatalf("预留 = %d, 期望 3000", got)
+	}
+	r1.Write(make([]byte, 1000))
+	if got := reservedBytes(dev); got != 2000 || r1.left != 2000 {
+		t.Errorf("写入1000字节后预留 = %d（剩余%d）, 期望 2000", got, r1.left)
+	}
+
+	r2, err := checkDiskSpace(src, dst, 0)
+	if err != nil || r2 == nil {
+		t.Fatalf("第二次 checkDiskSpace() = %v, %v", r2, err)
+	}
+	if got := reservedBytes(dev); got != 5000 {
+		t.Errorf("两次预留 = %d, 期望 5000", got)
+	}
+	r1.Write(make([]byte, 5000))
+	if got := reservedBytes(dev); got != 3000 || r1.left != 0 {
+		t.Errorf("写入超过预留后 = %d（剩余%d）, 期望 3000", got, r1.left)
+	}
+
+	r1.release()
+	r2.release()
+	r2.release()
+	if got := reservedBytes(dev); got != 0 {
+		t.Errorf("释放后预留 = %d, 期望 0", got)
+	}
+	var none *spaceReservation
+	none.release()
+}
+
+// TestCheckDiskSpaceShort 可用空间扣除其他任务的预留后不足时报告ENOSPC，且不预留
+func TestCheckDiskSpaceShort(t *testing.T) {
+	dir := t.TempDir()
+	dev := spaceDevice(t, dir)
+	src := filepath.Join(dir, "src")
+	if err := os.WriteFile(src, make([]byte, 1<<20), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(dir, "dst")
+
+	if r, err := checkDiskSpace(src, dst, 1<<62); !errors.Is(err, errNoSpace) || r != nil {
+		t.Errorf("安全余量超过可用空间时 checkDiskSpace() = %v, %v, 期望 ENOSPC", r, err)
+	}
+	if got := reservedBytes(dev); got != 0 {
+		t.Errorf("检查失败后预留 = %d, 期望 0", got)
+	}
+
+	// 其他任务预留了全部可用空间
+	free, err := getFreeDiskSpace(dir)
+	if err != nil {
+		t.Skip(err)
+	}
+	other := &spaceReservation{dev: dev, left: int64(free)}
+	spaceReservations.Lock()
+	spaceReservations.bytes[dev] += other.left
+	spaceReservations.Unlock()
+	defer other.release()
+	if _, err := checkDiskSpace(src, dst, 0); !errors.Is(err, errNoSpace) {
+		t.Errorf("其他任务已预留全部空间时 checkDiskSpace() 错误 = %v, 期望 ENOSPC", err)
+	}
+
+	// 其他任务写入后，已写入的部分不再重复计算
+	other.Write(make([]byte, 4<<20))
+	r, err := checkDiskSpace(src, dst, 0)
+	if err != nil {
+		t.Fatalf("其他任务写入后 checkDiskSpace() 返回错误: %v", err)
+	}
+	r.release()
+}
+
+// TestCopyFileReleasesReservation 复制过程中写入的字节全部释放
+func TestCopyFileReleasesReservation(t *testing.T) {
+	dir := t.TempDir()
+	dev := spaceDevice(t, dir)
+	src := filepath.Join(dir, "src")
+	if err := os.WriteFile(src, make([]byte, 3<<20), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(dir, "dst")
+
+	r, err := checkDiskSpace(src, dst, 0)
+	if err != nil || r == nil {
+		t.Fatalf("checkDiskSpace() = %v, %v", r, err)
+	}
+	defer r.release()
+	if _, _, _, err := copyFile(context.Background(), src, dst, nil, false, nil, r); err != nil {
+		t.Fatal(err)
+	}
+	if got := reservedBytes(dev); got != 0 || r.left != 0 {
+		t.Errorf("复制完成后预留 = %d（剩余%d）, 期望 0", got, r.left)
+	}
+}
+
+// TestSpaceNeeds 预检按冲突策略计算已存在的目标文件：跳过和报错不写入，保留两者写入完整文件，
+// 覆盖类策略和同步模式按差额计算，同一文件系统内的移动不需要空间
+func TestSpaceNeeds(t *testing.T) {
+	tests := []struct {
+		name   string
+		mode   string
+		policy ConflictPolicy
+		want   int64
+	}{
+		{"默认跳过", "copy", "", 100},
+		{"跳过", "copy", OnConflictSkip, 100},
+		{"报错", "copy", OnConflictFail, 100},
+		{"覆盖", "copy", OnConflictOverwrite, 220},
+		{"内容不同时覆盖", "copy", OnConflictDifferent, 220},
+		{"源文件较新时覆盖", "copy", OnConflictNewer, 160},
+		{"保留两者", "copy", OnConflictKeepBoth, 300},
+		{"同步按差额", "sync", OnConflictSkip, 220},
+		{"同一文件系统内移动", "move", OnConflictOverwrite, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			src := filepath.Join(root, "src")
+			dest := filepath.Join(root, "dest")
+			spaceDevice(t, root)
+			for _, dir := range []string{filepath.Join(src, "sub"), filepath.Join(dest, "sub")} {
+				if err := os.MkdirAll(dir, 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// new不存在于目标目录；old的已有目标文件较旧，recent的已有目标文件较新
+			now := time.Now()
+			var files []string
+			for _, f := range []struct {
+				rel      string
+				existing bool
+				dstTime  time.Time
+			}{
+				{"new", false, now},
+				{filepath.Join("sub", "old"), true, now.Add(-time.Hour)},
+				{"recent", true, now.Add(time.Hour)},
+			} {
+				path := filepath.Join(src, f.rel)
+				if err := os.WriteFile(path, make([]byte, 100), 0644); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Chtimes(path, now, now); err != nil {
+					t.Fatal(err)
+				}
+				files = append(files, path)
+				if !f.existing {
+					continue
+				}
+				target := filepath.Join(dest, f.rel)
+				if err := os.WriteFile(target, make([]byte, 40), 0644); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Chtimes(target, f.dstTime, f.dstTime); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			op, ok := LookupOperator(tt.mode)
+			if !ok {
+				t.Fatalf("%s 操作未注册", tt.mode)
+			}
+			s := NewScheduler(SchedulerConfig{Mode: tt.mode, SrcRoot: src, DestRoot: dest, OnConflict: tt.policy})
+			needs, err := s.spaceNeeds(context.Background(), op, func(fn func(path string) error) error {
+				for _, f := range files {
+					if err := fn(f); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got int64
+			for _, n := range needs {
+				got += n.bytes
+			}
+			if got != tt.want {
+				t.Errorf("需要写入 = %d, 期望 %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFormatBytes 计划和空间检查使用同一格式，结果可由ParseSize解析回近似的字节数
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1536, "1.5 KB"},
+		{DefaultSpaceMargin, "64.0 MB"},
+		{3 << 30, "3.0 GB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got := FormatBytes(tt.n)
+			if got != tt.want {
+				t.Errorf("FormatBytes(%d) = %q, 期望 %q", tt.n, got, tt.want)
+			}
+			if n, err := ParseSize(got); err != nil || n != tt.n {
+				t.Errorf("ParseSize(%q) = %d, %v, 期望 %d", got, n, err, tt.n)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+//go:build windows
+
+package fileutil
+
+import (
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32                = syscall.NewLazyDLL("kernel32.dll")
+	procGetDiskFreeSpaceExW = kernel32.NewProc("GetDiskFreeSpaceExW")
+	procGetVolumePathNameW  = kernel32.NewProc("GetVolumePathNameW")
+)
+
+// getFreeDiskSpace 获取path所在卷中当前用户可用的字节数
+func getFreeDiskSpace(path string) (uint64, error) {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var free uint64
+	if r, _, err := procGetDiskFreeSpaceExW.Call(uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(&free)), 0, 0); r == 0 {
+		return 0, err
+	}
+	return free, nil
+}
+
+// deviceID 返回path所在卷的挂载路径（同一卷上的路径标识相同）
+func deviceID(path string) (string, error) {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return "", err
+	}
+	buf := make([]uint16, syscall.MAX_PATH+1)
+	if r, _, err := procGetVolumePathNameW.Call(uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf))); r == 0 {
+		return "", err
+	}
+	return strings.ToLower(syscall.UTF16ToString(buf)), nil
+}
@@ -0,0 +1,125 @@
+package fileutil
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// stagesSwaps 判断操作是否在源目录内原地重命名，需要两阶段处理互相占用的路径
+func stagesSwaps(info OperatorInfo) bool {
+	return info.Renames && !info.NeedsDest
+}
+
+// stageSwaps 两阶段重命名的第一阶段：源路径恰好是本批次其他文件目标路径、且自身也会被重命名的文件，先移到同目录的暂存路径，
+// 保证 a->b、b->a 的交换和 a->b、b->c 的链式重命名由Worker并发处理时不会互相覆盖
+// 同时预先生成所有文件的目标路径（暂存后文件名已改变，无法再按原文件名生成）
+func (s *Scheduler) stageSwaps(ctx context.Context, op Operator, files []string) error {
+	planner, ok := op.(Planner)
+	if !ok || !stagesSwaps(op.Info()) {
+		return nil
+	}
+
+	sources := make(map[string]string, len(files))
+	for _, f := range files {
+		sources[absPath(f)] = f
+	}
+	if s.targets == nil {
+		s.targets = make(map[string]string, len(files))
+	}
+	// moving 目标路径有效且与原路径不同、确实会被重命名的文件
+	moving := make(map[string]bool, len(files))
+	for _, f := range files {
+		target, err := planner.Target(ctx, s.newTask(f))
+		if err != nil {
+			// 无法生成目标路径的文件由Worker处理时报告（或因不匹配而跳过）
+			continue
+		}
+		s.targets[f] = target
+		if absPath(target) != absPath(f) {
+			moving[f] = true
+		}
+	}
+	// 只暂存自身也会被重命名的占用者；不会被重命名的文件仍在原处，
+	// 以它为目标的文件由Worker按冲突策略处理
+	var staging []string
+	for _, f := range files {
+		if !moving[f] {
+			continue
+		}
+		if owner, ok := sources[absPath(s.targets[f])]; ok && moving[owner] {
+			staging = append(staging, owner)
+		}
+	}
+	if len(staging) == 0 {
+		return nil
+	}
+
+	s.staged = make(map[string]string, len(staging))
+	for _, f := range staging {
+		if _, ok := s.staged[f]; ok {
+			continue
+		}
+		tmp, err := stageFile(f)
+		if err != nil {
+			s.restoreStaged()
+			return fmt.Errorf("暂存 %s 失败: %w", f, err)
+		}
+		s.staged[f] = tmp
+		if s.undo != nil {
+			if err := s.undo.recordMove(f, tmp); err != nil && s.summary.UndoErr == nil {
+				s.summary.UndoErr = err
+			}
+		}
+	}
+	return nil
+}
+
+// stageFile 把文件移到同目录下唯一的暂存路径
+func stageFile(path string) (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*"+stagedFileSuffix)
+	if err != nil {
+		return "", asCreateError(err)
+	}
+	tmp := f.Name()
+	f.Close()
+	if err := os.Rename(path, tmp); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return tmp, nil
+}
+
+// finishStaged 暂存文件的任务结束：已重命名到目标路径时不再跟踪，否则移回原路径（调用方持有s.mu）
+func (s *Scheduler) finishStaged(res Result) {
+	tmp, ok := s.staged[res.OldName]
+	if !ok {
+		return
+	}
+	if res.Err != nil || res.Skipped || res.Conflict == ConflictSkipped {
+		s.restoreOne(res.OldName, tmp)
+	}
+	delete(s.staged, res.OldName)
+}
+
+// restoreStaged 把所有未完成重命名的暂存文件移回原路径（调用方持有s.mu或Worker尚未启动）
+func (s *Scheduler) restoreStaged() {
+	for orig, tmp := range s.staged {
+		s.restoreOne(orig, tmp)
+		delete(s.staged, orig)
+	}
+}
+
+// restoreOne 把暂存文件移回原路径，原路径已被占用或移动失败时保留在暂存路径并记入统计
+func (s *Scheduler) restoreOne(orig, tmp string) {
+	if _, err := os.Lstat(orig); err == nil || os.Rename(tmp, orig) != nil {
+		s.summary.Stranded = append(s.summary.Stranded, tmp)
+		return
+	}
+	if s.undo != nil {
+		if err := s.undo.recordMove(tmp, orig); err != nil && s.summary.UndoErr == nil {
+			s.summary.UndoErr = err
+		}
+	}
+}
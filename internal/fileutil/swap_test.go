@@ -0,0 +1,242 @@
+package fileutil
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// newSwapScheduler 创建按指定目标路径原地重命名的调度器，并在目录中写入文件（内容为文件名）
+func newSwapScheduler(t *testing.T, dir string, moves [][2]string) (*Scheduler, Operator, []string) {
+	t.Helper()
+	op, ok := LookupOperator("rename")
+	if !ok {
+		t.Fatal("rename 操作未注册")
+	}
+	s := NewScheduler(SchedulerConfig{Mode: "rename", SrcRoot: dir})
+	s.targets = map[string]string{}
+	var files []string
+	for _, m := range moves {
+		path := filepath.Join(dir, m[0])
+		if err := os.WriteFile(path, []byte(m[0]), 0644); err != nil {
+			t.Fatal(err)
+		}
+		s.targets[path] = filepath.Join(dir, m[1])
+		files = append(files, path)
+	}
+	return s, op, files
+}
+
+// TestStageSwaps 只暂存源路径是其他文件目标路径的文件，处理完成后交换和链式重命名互不覆盖
+func TestStageSwaps(t *testing.T) {
+	tests := []struct {
+		name   string
+		moves  [][2]string       // 原文件名 -> 目标文件名
+		staged []string          // 需要暂存的文件
+		want   map[string]string // 处理完成后的文件名和内容
+	}{
+		{
+			name:   "两个文件交换",
+			moves:  [][2]string{{"a", "b"}, {"b", "a"}},
+			staged: []string{"a", "b"},
+			want:   map[string]string{"a": "b", "b": "a"},
+		},
+		{
+			name:   "三个文件轮换",
+			moves:  [][2]string{{"a", "b"}, {"b", "c"}, {"c", "a"}},
+			staged: []string{"a", "b", "c"},
+			want:   map[string]string{"a": "c", "b": "a", "c": "b"},
+		},
+		{
+			name:   "链式重命名",
+			moves:  [][2]string{{"a", "b"}, {"b", "c"}},
+			staged: []string{"b"},
+			want:   map[string]string{"b": "a", "c": "b"},
+		},
+		{
+			name:   "目标互不占用",
+			moves:  [][2]string{{"a", "x"}, {"b", "y"}},
+			staged: nil,
+			want:   map[string]string{"x": "a", "y": "b"},
+		},
+		{
+			name:   "目标与源路径相同",
+			moves:  [][2]string{{"a", "a"}, {"b", "c"}},
+			staged: nil,
+			want:   map[string]string{"a": "a", "c": "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			s, op, files := newSwapScheduler(t, dir, tt.moves)
+			if err := s.stageSwaps(context.Background(), op, files); err != nil {
+				t.Fatal(err)
+			}
+
+			var staged []string
+			for orig, tmp := range s.staged {
+				staged = append(staged, filepath.Base(orig))
+				if !strings.HasSuffix(tmp, stagedFileSuffix) || filepath.Dir(tmp) != dir {
+					t.Errorf("暂存路径 = %q, 期望在同目录下以 %s 结尾", tmp, stagedFileSuffix)
+				}
+				if _, err := os.Lstat(orig); !os.IsNotExist(err) {
+					t.Errorf("%s 暂存后原路径仍存在", orig)
+				}
+			}
+			slices.Sort(staged)
+			if !slices.Equal(staged, tt.staged) {
+				t.Errorf("暂存的文件 = %v, 期望 %v", staged, tt.staged)
+			}
+
+			for _, f := range files {
+				res := op.Apply(context.Background(), s.newTask(f))
+				if res.Err != nil {
+					t.Fatalf("重命名 %s 失败: %v", f, res.Err)
+				}
+				s.finishStaged(res)
+			}
+			if len(s.staged) != 0 {
+				t.Errorf("处理完成后仍有暂存记录: %v", s.staged)
+			}
+			if got := readDir(t, dir); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("处理后的文件 = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRestoreStaged 未完成重命名的暂存文件移回原路径，原路径已被占用时保留在暂存路径并记入统计
+func TestRestoreStaged(t *testing.T) {
+	tests := []struct {
+		name     string
+		moves    [][2]string       // 原文件名 -> 目标文件名
+		done     []string          // 恢复前已完成重命名的文件
+		failed   string            // 恢复前处理失败的文件
+		occupy   string            // 恢复前被其他文件占用的原路径
+		want     map[string]string // 恢复后的文件名和内容（不含仍在暂存路径的文件）
+		stranded int
+	}{
+		{
+			name:  "都未处理时全部移回",
+			moves: [][2]string{{"a", "b"}, {"b", "a"}},
+			want:  map[string]string{"a": "a", "b": "b"},
+		},
+		{
+			name:     "原路径被占用时保留在暂存路径",
+			moves:    [][2]string{{"a", "b"}, {"b", "a"}},
+			occupy:   "a",
+			want:     map[string]string{"a": "other", "b": "b"},
+			stranded: 1,
+		},
+		{
+			name:   "处理失败的文件立即移回",
+			moves:  [][2]string{{"a", "b"}, {"b", "c"}},
+			failed: "b",
+			want:   map[string]string{"a": "a", "b": "b"},
+		},
+		{
+			name:     "交换中一方已完成",
+			moves:    [][2]string{{"a", "b"}, {"b", "a"}},
+			done:     []string{"a"},
+			want:     map[string]string{"b": "a"},
+			stranded: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			s, op, files := newSwapScheduler(t, dir, tt.moves)
+			if err := s.stageSwaps(context.Background(), op, files); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, name := range tt.done {
+				res := op.Apply(context.Background(), s.newTask(filepath.Join(dir, name)))
+				if res.Err != nil {
+					t.Fatalf("重命名 %s 失败: %v", name, res.Err)
+				}
+				s.finishStaged(res)
+			}
+			if tt.failed != "" {
+				s.finishStaged(Result{OldName: filepath.Join(dir, tt.failed), Err: os.ErrPermission})
+				if _, ok := s.staged[filepath.Join(dir, tt.failed)]; ok {
+					t.Errorf("处理失败后 %s 仍在暂存记录中", tt.failed)
+				}
+			}
+			if tt.occupy != "" {
+				if err := os.WriteFile(filepath.Join(dir, tt.occupy), []byte("other"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			s.restoreStaged()
+
+			if len(s.staged) != 0 {
+				t.Errorf("恢复后仍有暂存记录: %v", s.staged)
+			}
+			if len(s.summary.Stranded) != tt.stranded {
+				t.Errorf("Stranded = %v, 期望 %d 个", s.summary.Stranded, tt.stranded)
+			}
+			got := readDir(t, dir)
+			for _, tmp := range s.summary.Stranded {
+				if _, ok := got[filepath.Base(tmp)]; !ok {
+					t.Errorf("暂存文件 %s 不存在", tmp)
+				}
+				delete(got, filepath.Base(tmp))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("恢复后的文件 = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestStageSwapsUnmatchedOwner 目标路径被不会重命名的文件（不匹配规则）占用时不暂存该文件，按冲突策略处理
+func TestStageSwapsUnmatchedOwner(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy ConflictPolicy
+		want   map[string]string
+	}{
+		{"跳过", OnConflictSkip, map[string]string{"a.txt": "AAA", "b.txt": "BBB"}},
+		{"覆盖", OnConflictOverwrite, map[string]string{"b.txt": "AAA"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range map[string]string{"a.txt": "AAA", "b.txt": "BBB"} {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			s := NewScheduler(SchedulerConfig{
+				Mode:       "rename",
+				SrcRoot:    dir,
+				Workers:    4,
+				Regex:      &RegexRule{Pattern: "^a$", Replace: "b"},
+				OnConflict: tt.policy,
+			})
+			results, err := s.Start(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for range results {
+			}
+			summary := s.Wait()
+
+			if len(summary.Stranded) != 0 {
+				t.Errorf("Stranded = %v, 期望没有暂存文件残留", summary.Stranded)
+			}
+			if got := readDir(t, dir); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("处理后的文件 = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+}
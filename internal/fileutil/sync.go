@@ -0,0 +1,163 @@
+package fileutil
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// 同步模式下判断文件是否变化的方式
+const (
+	CompareSizeMtime = "size_mtime" // 比较大小和修改时间（精确到秒）
+	CompareHash      = "hash"       // 比较完整哈希
+)
+
+// CompareModes 返回所有支持的比较方式
+func CompareModes() []string {
+	return []string{CompareSizeMtime, CompareHash}
+}
+
+// validateSync 校验同步模式特有的参数
+func validateSync(t Task) error {
+	switch t.Compare {
+	case "", CompareSizeMtime, CompareHash:
+		return nil
+	}
+	return fmt.Errorf("不支持的比较方式: %s（可选：%s/%s）", t.Compare, CompareSizeMtime, CompareHash)
+}
+
+// processSync 单向同步：目标文件不存在或已变化时复制，未变化时跳过
+func processSync(ctx context.Context, t Task) Result {
+	result := Result{OldName: t.Path}
+
+	srcInfo, err := os.Stat(t.Path)
+	if err != nil {
+		result.Err = fmt.Errorf("读取源文件信息失败: %w", err)
+		return result
+	}
+
+	newPath, err := targetPath(ctx, t, false)
+	if err != nil {
+		result.Err = fmt.Errorf("生成目标路径失败: %w", err)
+		return result
+	}
+	result.NewName = newPath
+
+	// 比较目标文件是否变化
+	if dstInfo, err := os.Stat(newPath); err == nil && dstInfo.Mode().IsRegular() && dstInfo.Size() == srcInfo.Size() {
+		if t.Compare == CompareHash {
+			srcHashes, err := calculateFileHashes(ctx, t.Path, t.Algorithms)
+			if err != nil {
+				result.Err = fmt.Errorf("计算源文件哈希失败: %w", err)
+				return result
+			}
+			dstHashes, err := calculateFileHashes(ctx, newPath, t.Algorithms)
+			if err != nil {
+				result.Err = fmt.Errorf("计算目标文件哈希失败: %w", err)
+				return result
+			}
+			if hashesEqual(srcHashes, dstHashes) {
+				// 内容相同但时间不同时顺便同步修改时间，之后可以按大小+修改时间快速比较
+				if !dstInfo.ModTime().Equal(srcInfo.ModTime()) {
+					os.Chtimes(newPath, srcInfo.ModTime(), srcInfo.ModTime())
+				}
+				result.SrcHashes = srcHashes
+				result.DstHashes = dstHashes
+				result.Verified = true
+				result.Unchanged = true
+				return result
+			}
+		} else if dstInfo.ModTime().Unix() == srcInfo.ModTime().Unix() {
+			result.Unchanged = true
+			return result
+		}
+	}
+
+	// 创建目标目录
+	if err := createDirectory(filepath.Dir(newPath)); err != nil {
+		result.Err = fmt.Errorf("创建目标目录失败: %w", err)
+		return result
+	}
+
+	// 检查并预留磁盘空间
+	reserved, err := reserveSpace(t, newPath)
+	if err != nil {
+		result.Err = fmt.Errorf("磁盘空间检查失败: %w", err)
+		return result
+	}
+	defer reserved.release()
+
+	srcHashes, dstHashes, metaErrs, err := copyFile(ctx, t.Path, newPath, t.Algorithms, t.StrictVerify, t.Preserve, reserved)
+	if err != nil {
+		result.Err = fmt.Errorf("复制文件失败: %w", err)
+		return result
+	}
+	result.MetaErrs = metaErrs
+
+	// 目标文件的修改时间与源文件保持一致，下次按大小+修改时间比较时才能识别为未变化；
+	// 不保留时间时也要设置修改时间（访问时间不变）
+	if !t.Preserve.orDefault().Has(MetaTimes) {
+		if err := os.Chtimes(newPath, time.Time{}, srcInfo.ModTime()); err != nil {
+			result.Err = fmt.Errorf("设置修改时间失败: %w", err)
+			return result
+		}
+	}
+
+	result.SrcHashes = srcHashes
+	result.DstHashes = dstHashes
+	result.Verified = hashesEqual(srcHashes, dstHashes)
+	result.VerifySkipped = dstHashes == nil
+	return result
+}
+
+// pruneDestination 删除destRoot下不在expected中的文件，以及删除后变空、且源目录中没有对应目录的子目录
+// 返回删除的文件数
+func pruneDestination(srcRoot, destRoot string, expected map[string]bool) (int, error) {
+	var extra, dirs []string
+	err := filepath.WalkDir(destRoot, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != destRoot {
+				dirs = append(dirs, path)
+			}
+			return nil
+		}
+		if isTempFile(d.Name()) {
+			return nil
+		}
+		if abs, err := filepath.Abs(path); err == nil && !expected[abs] {
+			extra = append(extra, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("扫描目标目录失败: %w", err)
+	}
+
+	deleted := 0
+	for _, path := range extra {
+		if err := os.Remove(path); err != nil {
+			return deleted, fmt.Errorf("删除 %s 失败: %w", path, err)
+		}
+		deleted++
+	}
+
+	// 由深到浅删除空目录（非空目录删除失败时忽略）
+	sort.Sort(sort.Reverse(sort.StringSlice(dirs)))
+	for _, dir := range dirs {
+		rel, err := filepath.Rel(destRoot, dir)
+		if err != nil {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(srcRoot, rel)); os.IsNotExist(err) {
+			os.Remove(dir)
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,98 @@
+package fileutil
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// tempFileSuffix 复制过程中临时文件的后缀，用于识别中断遗留的临时文件
+const tempFileSuffix = ".filetool-tmp"
+
+// createTempFile 在目标文件所在目录创建临时文件（与目标同一文件系统，保证可原子重命名）
+func createTempFile(dst string) (*os.File, error) {
+	f, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*"+tempFileSuffix)
+	return f, asCreateError(err)
+}
+
+// createExclusive 独占创建用于写入的新文件
+func createExclusive(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	return f, asCreateError(err)
+}
+
+// asCreateError 把创建文件失败的操作名由open改为create
+// （标准库以读、写方式打开文件失败时都报告为open，classifyError据此区分写入错误）
+func asCreateError(err error) error {
+	if pathErr, ok := err.(*fs.PathError); ok && pathErr.Op == "open" {
+		pathErr.Op = "create"
+	}
+	return err
+}
+
+// writeFileAtomic 原子写出文件：先写入同目录的临时文件并落盘，再重命名为目标文件
+func writeFileAtomic(path string, write func(w io.Writer) error) (err error) {
+	tmpFile, err := createTempFile(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmpFile.Close()
+			os.Remove(tmpFile.Name())
+		}
+	}()
+
+	if err = write(tmpFile); err != nil {
+		return err
+	}
+	if err = tmpFile.Sync(); err != nil {
+		return err
+	}
+	if err = tmpFile.Chmod(0644); err != nil {
+		return err
+	}
+	if err = tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), path)
+}
+
+// stagedFileSuffix 两阶段重命名时暂存文件的后缀
+// 暂存的是用户文件本身，启动时不会像临时文件一样被清理，中断时可通过撤销日志还原
+const stagedFileSuffix = ".filetool-swap"
+
+// isStagedFile 判断文件名是否为两阶段重命名的暂存文件
+func isStagedFile(name string) bool {
+	return strings.HasPrefix(name, ".") && strings.HasSuffix(name, stagedFileSuffix)
+}
+
+// isTempFile 判断文件名是否为本工具生成的临时文件
+func isTempFile(name string) bool {
+	return strings.HasPrefix(name, ".") && strings.HasSuffix(name, tempFileSuffix)
+}
+
+// CleanStaleTempFiles 删除root下之前中断的复制遗留的临时文件，返回删除数量
+// 注意：不要在同一目录仍有其他批处理运行时调用
+func CleanStaleTempFiles(root string) (int, error) {
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return 0, nil
+	}
+
+	removed := 0
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// 无法访问的子目录不影响清理其余部分
+			return nil
+		}
+		if d.Type().IsRegular() && isTempFile(d.Name()) {
+			if err := os.Remove(path); err == nil {
+				removed++
+			}
+		}
+		return nil
+	})
+	return removed, err
+}
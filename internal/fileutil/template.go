@@ -0,0 +1,262 @@
+package fileutil
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// maxTemplateLength 重命名模板的最大长度
+const maxTemplateLength = 512
+
+// RenameTemplate 编译后的重命名模板（text/template语法），执行结果为新的文件名
+// 结果中可以包含 / 以移动到子目录，但不能是绝对路径或跳出原目录
+type RenameTemplate struct {
+	text string
+	tmpl *template.Template
+}
+
+// RenameData 重命名模板可使用的字段
+type RenameData struct {
+	Name    string    // 不含扩展名的文件名
+	Ext     string    // 扩展名（含.，没有扩展名时为空）
+	Dir     string    // 相对源目录的所在目录（/分隔，位于源目录根下时为空）
+	Parent  string    // 所在目录的名称
+	Size    int64     // 文件大小（字节）
+	ModTime time.Time // 修改时间
+	Seq     int       // 序号（默认按文件名排序从1开始，可通过序号设置调整）
+	Num     string    // 按序号设置补零后的序号
+
+	ctx       context.Context // 任务的上下文（取消时停止计算哈希）
+	path      string          // 文件完整路径（计算哈希用）
+	algorithm string          // 计算哈希使用的算法
+	hash      string          // 已计算的哈希
+	sample    bool            // 示例数据（校验模板时使用，不读取文件）
+}
+
+// sampleHash 校验模板时示例数据使用的哈希
+const sampleHash = "d41d8cd98f00b204e9800998ecf8427e"
+
+// Hash 返回文件哈希（十六进制）的前n位，首次调用时才读取文件计算
+func (d *RenameData) Hash(n int) (string, error) {
+	if n <= 0 {
+		return "", fmt.Errorf("哈希长度必须大于0")
+	}
+	if d.hash == "" {
+		if d.sample {
+			d.hash = sampleHash
+		} else {
+			sums, err := calculateFileHashes(d.ctx, d.path, []string{d.algorithm})
+			if err != nil {
+				return "", fmt.Errorf("计算哈希失败: %w", err)
+			}
+			d.hash = sums[d.algorithm]
+		}
+	}
+	if n > len(d.hash) {
+		n = len(d.hash)
+	}
+	return d.hash[:n], nil
+}
+
+// templateFuncs 重命名模板可使用的函数
+var templateFuncs = template.FuncMap{
+	// pad 把数字补零到指定宽度，例如 {{pad .Seq 3}} -> 001
+	"pad": func(n, width int) string {
+		return fmt.Sprintf("%0*d", width, n)
+	},
+	"lower":   strings.ToLower,
+	"upper":   strings.ToUpper,
+	"trim":    strings.TrimSpace,
+	"replace": func(s, old, new string) string { return strings.ReplaceAll(s, old, new) },
+}
+
+// TemplateFields 返回模板字段和函数的说明（用于帮助信息）
+func TemplateFields() []string {
+	return []string{
+		"{{.Name}}  不含扩展名的文件名",
+		"{{.Ext}}  扩展名（含.）",
+		"{{.Dir}}  相对源目录的所在目录",
+		"{{.Parent}}  所在目录的名称",
+		"{{.Size}}  文件大小（字节）",
+		`{{.ModTime.Format "20060102"}}  修改时间（Go时间格式）`,
+		"{{.Seq}} / {{pad .Seq 3}}  序号 / 补零到3位的序号",
+		"{{.Num}}  按序号设置补零的序号（默认补到最大序号的位数）",
+		"{{.Hash 8}}  文件哈希的前8位",
+		`{{lower .Name}} {{upper .Ext}} {{trim .Name}} {{replace .Name " " "_"}}  大小写转换、去除首尾空白、替换`,
+	}
+}
+
+// ParseRenameTemplate 解析并校验重命名模板：用示例数据执行一次，确保语法、字段和结果都有效
+func ParseRenameTemplate(text string) (*RenameTemplate, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("重命名模板不能为空")
+	}
+	if len(text) > maxTemplateLength {
+		return nil, fmt.Errorf("重命名模板长度不能超过%d个字符", maxTemplateLength)
+	}
+	tmpl, err := template.New("rename").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("重命名模板语法错误: %w", err)
+	}
+	rt := &RenameTemplate{text: text, tmpl: tmpl}
+
+	sample := &RenameData{
+		Name:    "example",
+		Ext:     ".txt",
+		Dir:     "photos/2024",
+		Parent:  "2024",
+		Size:    1024,
+		ModTime: time.Now(),
+		Seq:     1,
+		Num:     "001",
+		sample:  true,
+	}
+	if _, err := rt.Execute(sample); err != nil {
+		return nil, err
+	}
+	return rt, nil
+}
+
+// String 返回模板原文
+func (rt *RenameTemplate) String() string {
+	return rt.text
+}
+
+// Execute 执行模板，返回新的文件名（可能包含子目录，使用系统路径分隔符）
+func (rt *RenameTemplate) Execute(data *RenameData) (string, error) {
+	var b strings.Builder
+	if err := rt.tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("执行重命名模板失败: %w", err)
+	}
+	name := b.String()
+	if strings.TrimSpace(name) == "" {
+		return "", fmt.Errorf("重命名模板的结果为空")
+	}
+	if strings.ContainsAny(name, "\x00\n\r") {
+		return "", fmt.Errorf("重命名模板的结果包含非法字符: %q", name)
+	}
+	name = filepath.FromSlash(name)
+	if !filepath.IsLocal(name) || strings.HasSuffix(name, string(filepath.Separator)) {
+		return "", fmt.Errorf("重命名模板的结果不是有效的相对文件名: %q", name)
+	}
+	return filepath.Clean(name), nil
+}
+
+// newRenameData 读取文件信息，生成模板数据
+func newRenameData(ctx context.Context, t Task, relPath string) (*RenameData, error) {
+	info, err := os.Stat(t.Path)
+	if err != nil {
+		return nil, err
+	}
+	filename := filepath.Base(relPath)
+	ext := filepath.Ext(filename)
+	dir := filepath.ToSlash(filepath.Dir(relPath))
+	if dir == "." {
+		dir = ""
+	}
+	return &RenameData{
+		Name:      filename[:len(filename)-len(ext)],
+		Ext:       ext,
+		Dir:       dir,
+		Parent:    filepath.Base(filepath.Dir(absPath(t.Path))),
+		Size:      info.Size(),
+		ModTime:   info.ModTime(),
+		Seq:       t.Seq,
+		Num:       formatSeq(t.Seq, t.SeqWidth),
+		ctx:       ctx,
+		path:      t.Path,
+		algorithm: algorithmsOf(t.Algorithms)[0],
+	}, nil
+}
+
+// templatePath 按重命名模板生成新的文件路径（模板结果替换文件名，所在目录保持不变）
+func templatePath(ctx context.Context, t Task) (string, error) {
+	relPath := filepath.Base(t.Path)
+	if t.SrcRoot != "" {
+		var err error
+		if relPath, err = filepath.Rel(t.SrcRoot, t.Path); err != nil {
+			return "", err
+		}
+	}
+	data, err := newRenameData(ctx, t, relPath)
+	if err != nil {
+		return "", err
+	}
+	name, err := t.Template.Execute(data)
+	if err != nil {
+		return "", err
+	}
+	if t.DestRoot != "" {
+		return filepath.Join(t.DestRoot, filepath.Dir(relPath), name), nil
+	}
+	return filepath.Join(filepath.Dir(t.Path), name), nil
+}
+
+// RenamePreview 重命名预览中的一个文件
+type RenamePreview struct {
+	Old string // 原路径（相对源目录）
+	New string // 新路径（相对源目录或目标目录）
+	Err error  // 无法生成新名称的原因
+}
+
+// PreviewRename 按调度配置中的重命名规则（模板或前缀/后缀）预览源目录中前limit个文件的新名称
+// 使用序号时扫描全部文件后按序号顺序预览；只读取文件信息（模板使用Hash时会读取文件内容），不修改文件系统
+func PreviewRename(ctx context.Context, cfg SchedulerConfig, limit int) ([]RenamePreview, error) {
+	if cfg.SrcRoot == "" {
+		return nil, fmt.Errorf("未指定源目录")
+	}
+	op, ok := LookupOperator(cfg.Mode)
+	if !ok {
+		return nil, fmt.Errorf("不支持的操作模式: %s", cfg.Mode)
+	}
+	s := NewScheduler(cfg)
+	if err := s.prepare(); err != nil {
+		return nil, err
+	}
+	if err := op.Validate(s.newTask("")); err != nil {
+		return nil, err
+	}
+
+	numbered := s.template != nil || cfg.Numbering != nil
+	var files []string
+	_, err := walkFiles(cfg.SrcRoot, nil, s.filter, func(path string) error {
+		files = append(files, path)
+		if !numbered && len(files) >= limit {
+			return fs.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("扫描源目录失败: %w", err)
+	}
+	s.numberFiles(files)
+	if numbered {
+		sort.SliceStable(files, func(i, j int) bool { return s.seq[files[i]] < s.seq[files[j]] })
+		if len(files) > limit {
+			files = files[:limit]
+		}
+	}
+
+	previews := make([]RenamePreview, 0, len(files))
+	for _, f := range files {
+		t := s.newTask(f)
+		t.DestRoot = ""
+		p := RenamePreview{}
+		p.Old, _ = filepath.Rel(cfg.SrcRoot, f)
+		newPath, err := targetPath(ctx, t, true)
+		if err != nil {
+			p.Err = err
+		} else {
+			p.New, _ = filepath.Rel(cfg.SrcRoot, newPath)
+		}
+		previews = append(previews, p)
+	}
+	return previews, nil
+}
@@ -0,0 +1,37 @@
+package fileutil
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestTemplatePathHash 模板使用Hash时按任务的上下文计算哈希，取消后不再读取文件
+func TestTemplatePathHash(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := ParseRenameTemplate("{{.Hash 8}}{{.Ext}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	task := Task{Path: path, SrcRoot: dir, Template: tmpl}
+
+	got, err := templatePath(context.Background(), task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "d41d8cd9.txt"); got != want {
+		t.Errorf("templatePath() = %q, 期望 %q", got, want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := templatePath(ctx, task); !errors.Is(err, context.Canceled) {
+		t.Errorf("取消后 templatePath() 错误 = %v, 期望 context.Canceled", err)
+	}
+}
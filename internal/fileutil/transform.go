@@ -0,0 +1,232 @@
+package fileutil
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// 文件名变换（按指定顺序依次作用于重命名后的文件名）
+const (
+	TransformNFC          = "nfc"                 // Unicode NFC规范化（Windows/Linux的常见形式）
+	TransformNFD          = "nfd"                 // Unicode NFD规范化（macOS HFS+的存储形式）
+	TransformLower        = "lower"               // 转为小写
+	TransformUpper        = "upper"               // 转为大写
+	TransformTitle        = "title"               // 单词首字母大写（不含扩展名）
+	TransformUnderscore   = "space_to_underscore" // 连续的空白字符替换为一个下划线
+	TransformStripIllegal = "strip_illegal"       // 去除Windows/FAT文件系统不允许的字符
+	TransformASCII        = "ascii"               // 转写为ASCII：去除变音符号，无法转写的字符替换为下划线
+	TransformTruncate     = "truncate"            // truncate:N 把文件名截断到N字节以内（保留扩展名，不拆分字符）
+)
+
+// transformLabels 文件名变换的显示名称
+var transformLabels = map[string]string{
+	TransformNFC:          "Unicode NFC规范化",
+	TransformNFD:          "Unicode NFD规范化",
+	TransformLower:        "转为小写",
+	TransformUpper:        "转为大写",
+	TransformTitle:        "单词首字母大写",
+	TransformUnderscore:   "空白转下划线",
+	TransformStripIllegal: "去除Windows/FAT非法字符",
+	TransformASCII:        "转写为ASCII",
+	TransformTruncate:     "截断到N字节（truncate:N）",
+}
+
+// Transforms 按推荐的组合顺序返回所有文件名变换
+func Transforms() []string {
+	return []string{
+		TransformNFC, TransformNFD, TransformASCII, TransformLower, TransformUpper, TransformTitle,
+		TransformUnderscore, TransformStripIllegal, TransformTruncate,
+	}
+}
+
+// TransformLabel 返回文件名变换的显示名称
+func TransformLabel(name string) string {
+	if label, ok := transformLabels[name]; ok {
+		return label
+	}
+	return name
+}
+
+// maxFilenameBytes 常见文件系统允许的文件名最大字节数
+const maxFilenameBytes = 255
+
+// TransformChain 编译后的文件名变换链
+type TransformChain struct {
+	steps []func(string) string
+}
+
+// ParseTransforms 校验并编译文件名变换链，specs按顺序执行，例如 ["nfc", "lower", "truncate:100"]
+func ParseTransforms(specs []string) (*TransformChain, error) {
+	chain := &TransformChain{}
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		name, arg, hasArg := strings.Cut(spec, ":")
+		if hasArg && name != TransformTruncate {
+			return nil, fmt.Errorf("文件名变换 %s 不接受参数", name)
+		}
+
+		var step func(string) string
+		switch name {
+		case TransformNFC:
+			step = norm.NFC.String
+		case TransformNFD:
+			step = norm.NFD.String
+		case TransformLower:
+			step = strings.ToLower
+		case TransformUpper:
+			step = strings.ToUpper
+		case TransformTitle:
+			step = titleName
+		case TransformUnderscore:
+			step = spacesToUnderscore
+		case TransformStripIllegal:
+			step = stripIllegal
+		case TransformASCII:
+			step = toASCII
+		case TransformTruncate:
+			limit, err := strconv.Atoi(arg)
+			if !hasArg || err != nil || limit < 1 || limit > maxFilenameBytes {
+				return nil, fmt.Errorf("截断长度应为1到%d之间的整数，例如 truncate:100", maxFilenameBytes)
+			}
+			step = func(s string) string { return truncateName(s, limit) }
+		default:
+			return nil, fmt.Errorf("不支持的文件名变换: %s（可选：%s）", name, strings.Join(Transforms(), "/"))
+		}
+		chain.steps = append(chain.steps, step)
+	}
+	if len(chain.steps) == 0 {
+		return nil, nil
+	}
+	return chain, nil
+}
+
+// Apply 依次执行变换，返回新的文件名
+func (c *TransformChain) Apply(filename string) (string, error) {
+	for _, step := range c.steps {
+		filename = step(filename)
+	}
+	if filename == "" || filename == "." || filename == ".." {
+		return "", fmt.Errorf("变换后的文件名为空或无效: %q", filename)
+	}
+	if strings.ContainsAny(filename, "/\x00") {
+		return "", fmt.Errorf("变换后的文件名包含非法字符: %q", filename)
+	}
+	return filename, nil
+}
+
+// transformPath 对路径中的文件名执行变换链（所在目录不变）
+func transformPath(path string, chain *TransformChain) (string, error) {
+	name, err := chain.Apply(filepath.Base(path))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), name), nil
+}
+
+// titleName 文件名（不含扩展名）中每个单词首字母大写，其余字母保持不变
+func titleName(s string) string {
+	ext := filepath.Ext(s)
+	return cases.Title(language.Und, cases.NoLower).String(s[:len(s)-len(ext)]) + ext
+}
+
+// spacesToUnderscore 把连续的空白字符替换为一个下划线
+func spacesToUnderscore(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			if !space {
+				b.WriteByte('_')
+			}
+			space = true
+			continue
+		}
+		space = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// windowsReserved Windows保留的设备名（不区分大小写，带扩展名也不允许）
+var windowsReserved = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// stripIllegal 去除Windows/FAT不允许的字符（<>:"/\|?* 和控制字符）以及结尾的点和空格，
+// 与保留设备名同名时追加下划线
+func stripIllegal(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(`<>:"/\|?*`, r) {
+			return -1
+		}
+		return r
+	}, s)
+	s = strings.TrimRight(s, ". ")
+	stem, _, _ := strings.Cut(s, ".")
+	if windowsReserved[strings.ToUpper(strings.TrimRight(stem, " "))] {
+		s = stem + "_" + s[len(stem):]
+	}
+	return s
+}
+
+// asciiReplacements 无法通过去除变音符号转写的常见字母
+var asciiReplacements = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'Æ': "AE", 'œ': "oe", 'Œ': "OE", 'ø': "o", 'Ø': "O",
+	'đ': "d", 'Đ': "D", 'ł': "l", 'Ł': "L", 'þ': "th", 'Þ': "TH", 'ð': "d", 'Ð': "D",
+	'ı': "i", '‘': "'", '’': "'", '“': "\"", '”': "\"", '–': "-", '—': "-", '…': "...",
+}
+
+// toASCII 转写为ASCII：é -> e、ß -> ss，中文、emoji等无法转写的字符替换为下划线
+func toASCII(s string) string {
+	// 分解字符后去除组合变音符号（Transformer有内部状态，每次新建以便并发使用）
+	stripMarks := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	if stripped, _, err := transform.String(stripMarks, s); err == nil {
+		s = stripped
+	}
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r < utf8.RuneSelf:
+			b.WriteRune(r)
+		case asciiReplacements[r] != "":
+			b.WriteString(asciiReplacements[r])
+		case unicode.IsSpace(r):
+			b.WriteByte(' ')
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// truncateName 把文件名截断到limit字节以内，在字符边界处截断并尽量保留扩展名
+func truncateName(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	ext := filepath.Ext(s)
+	if len(ext) >= limit/2 {
+		ext = ""
+	}
+	stem := s[:len(s)-len(ext)]
+	n := limit - len(ext)
+	for n > 0 && !utf8.RuneStart(stem[n]) {
+		n--
+	}
+	return stem[:n] + ext
+}
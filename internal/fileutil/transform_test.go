@@ -0,0 +1,145 @@
+package fileutil
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// TestParseTransforms 只有truncate接受参数，截断长度必须在1到255之间
+func TestParseTransforms(t *testing.T) {
+	tests := []struct {
+		name    string
+		specs   []string
+		wantNil bool
+		wantErr string
+	}{
+		{"全部变换", Transforms()[:len(Transforms())-1], false, ""},
+		{"截断", []string{"truncate:100"}, false, ""},
+		{"忽略空白项", []string{" lower ", ""}, false, ""},
+		{"为空时不变换", []string{"", " "}, true, ""},
+		{"不接受参数", []string{"lower:1"}, false, "不接受参数"},
+		{"截断缺少长度", []string{"truncate"}, false, "截断长度"},
+		{"截断长度为0", []string{"truncate:0"}, false, "截断长度"},
+		{"截断长度超过上限", []string{"truncate:256"}, false, "截断长度"},
+		{"截断长度不是整数", []string{"truncate:x"}, false, "截断长度"},
+		{"不支持的变换", []string{"reverse"}, false, "不支持的文件名变换"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain, err := ParseTransforms(tt.specs)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseTransforms(%q) 错误 = %v, 期望包含 %q", tt.specs, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseTransforms(%q) 返回错误: %v", tt.specs, err)
+			}
+			if (chain == nil) != tt.wantNil {
+				t.Errorf("ParseTransforms(%q) = %v, 期望为nil: %v", tt.specs, chain, tt.wantNil)
+			}
+		})
+	}
+}
+
+// TestTransformChain 变换按指定顺序依次执行，结果为空或包含路径分隔符时报错
+func TestTransformChain(t *testing.T) {
+	tests := []struct {
+		name    string
+		specs   []string
+		in      string
+		want    string
+		wantErr string
+	}{
+		{"NFC", []string{"nfc"}, "cafe\u0301.txt", "caf\u00e9.txt", ""},
+		{"NFD", []string{"nfd"}, "caf\u00e9.txt", "cafe\u0301.txt", ""},
+		{"小写后空白转下划线", []string{"nfc", "lower", "space_to_underscore"}, "My  Photo.JPG", "my_photo.jpg", ""},
+		{"首字母大写不影响扩展名", []string{"title"}, "hello world.txt", "Hello World.txt", ""},
+		{"首字母大写保留其余字母", []string{"title"}, "iPhone photo.JPG", "IPhone Photo.JPG", ""},
+		{"先大写再小写", []string{"upper", "lower"}, "Ab.Txt", "ab.txt", ""},
+		{"转写为ASCII", []string{"ascii"}, "Straße Crème 中文.txt", "Strasse Creme __.txt", ""},
+		{"去除非法字符", []string{"strip_illegal"}, `a:b<c>?"|*.txt. `, "abc.txt", ""},
+		{"保留设备名", []string{"strip_illegal"}, "con.txt", "con_.txt", ""},
+		{"保留设备名无扩展名", []string{"strip_illegal"}, "LPT1", "LPT1_", ""},
+		{"去除控制字符", []string{"strip_illegal"}, "a\tb\x7f.txt", "ab.txt", ""},
+		{"截断保留扩展名", []string{"truncate:10"}, "abcdefghij.txt", "abcdef.txt", ""},
+		{"未超出时不截断", []string{"truncate:10"}, "abc.txt", "abc.txt", ""},
+		{"变换后再截断", []string{"ascii", "truncate:8"}, "Ärger-und-Frust.md", "Arger.md", ""},
+		{"变换后为空", []string{"strip_illegal"}, "...", "", "为空或无效"},
+		{"截断后为空", []string{"truncate:2"}, "中文", "", "为空或无效"},
+		{"结果为..", []string{"lower"}, "..", "", "为空或无效"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain, err := ParseTransforms(tt.specs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := chain.Apply(tt.in)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Apply(%q) 错误 = %v, 期望包含 %q", tt.in, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Apply(%q) 返回错误: %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("Apply(%q) = %q, 期望 %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestTruncateName 在字符边界处截断到limit字节以内，扩展名占一半以上时不保留扩展名
+func TestTruncateName(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    string
+		limit int
+		want  string
+	}{
+		{"中文在字符边界截断", "中文文件名.txt", 10, "中文.txt"},
+		{"中文不拆分字符", "中文文件名.txt", 11, "中文.txt"},
+		{"扩展名占一半时不保留", "中文文件名.txt", 9, "中文文"},
+		{"扩展名过长时不保留", "中文文件名.txt", 6, "中文"},
+		{"emoji不拆分", "😀😀.md", 8, "😀.md"},
+		{"组合字符按码点截断", "e\u0301e\u0301e\u0301.txt", 12, "e\u0301e\u0301e.txt"},
+		{"刚好等于上限", "中文.txt", 10, "中文.txt"},
+		{"上限内不足一个字符", "中文", 2, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateName(tt.in, tt.limit)
+			if got != tt.want {
+				t.Errorf("truncateName(%q, %d) = %q, 期望 %q", tt.in, tt.limit, got, tt.want)
+			}
+			if len(got) > tt.limit || !utf8.ValidString(got) {
+				t.Errorf("truncateName(%q, %d) = %q 超出上限或不是有效的UTF-8", tt.in, tt.limit, got)
+			}
+		})
+	}
+}
+
+// TestTransformPath 只变换文件名，所在目录不变
+func TestTransformPath(t *testing.T) {
+	chain, err := ParseTransforms([]string{"upper"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := filepath.Join("dir", "sub", "a b.txt")
+	got, err := transformPath(in, chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join("dir", "sub", "A B.TXT"); got != want {
+		t.Errorf("transformPath(%q) = %q, 期望 %q", in, got, want)
+	}
+}
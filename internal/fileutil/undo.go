@@ -0,0 +1,307 @@
+package fileutil
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// undoVersion 撤销日志格式版本
+const undoVersion = 1
+
+// undoHeader 撤销日志首行：记录批处理参数
+type undoHeader struct {
+	Version   int       `json:"version"`
+	Mode      string    `json:"mode"`
+	SrcRoot   string    `json:"src_root"`
+	DestRoot  string    `json:"dest_root,omitempty"`
+	Algorithm string    `json:"algorithm"`
+	Created   time.Time `json:"created"`
+}
+
+// UndoRecord 撤销日志中的一次文件移动（每行一条，按完成顺序追加）
+type UndoRecord struct {
+	Old      string `json:"old"`                // 原路径（绝对路径）
+	New      string `json:"new"`                // 新路径（绝对路径）
+	Hash     string `json:"hash,omitempty"`     // 新文件哈希
+	Replaced bool   `json:"replaced,omitempty"` // 新路径上原有的文件被覆盖（无法恢复）
+}
+
+// UndoLog 破坏性批处理（重命名、移动）的撤销日志，JSON Lines格式，逐条追加写入
+type UndoLog struct {
+	path      string
+	algorithm string
+	mu        sync.Mutex
+	file      *os.File
+	count     int
+}
+
+// DefaultUndoPath 返回本次批处理默认的撤销日志路径（位于用户缓存目录，按时间命名）
+func DefaultUndoPath(mode string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("获取缓存目录失败: %w", err)
+	}
+	name := fmt.Sprintf("%s-%s.jsonl", mode, time.Now().Format("20060102-150405.000"))
+	return filepath.Join(cacheDir, "filetool", "undo", name), nil
+}
+
+// createUndoLog 创建撤销日志并写入首行
+func createUndoLog(path string, cfg SchedulerConfig) (*UndoLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建撤销日志目录失败: %w", err)
+	}
+	f, err := createExclusive(path)
+	if err != nil {
+		return nil, fmt.Errorf("创建撤销日志失败: %w", err)
+	}
+
+	header := undoHeader{
+		Version:   undoVersion,
+		Mode:      cfg.Mode,
+		SrcRoot:   absPath(cfg.SrcRoot),
+		Algorithm: algorithmsOf(cfg.Algorithms)[0],
+		Created:   time.Now(),
+	}
+	if cfg.DestRoot != "" {
+		header.DestRoot = absPath(cfg.DestRoot)
+	}
+	l := &UndoLog{path: path, algorithm: header.Algorithm, file: f}
+	if err := l.writeLine(header); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// Path 返回撤销日志路径
+func (l *UndoLog) Path() string {
+	return l.path
+}
+
+// Count 返回已记录的条数
+func (l *UndoLog) Count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.count
+}
+
+// record 记录一次成功的重命名或移动（两阶段重命名时从暂存路径移出）
+func (l *UndoLog) record(res Result) error {
+	oldPath, newPath := absPath(res.OldName), absPath(res.NewName)
+	if res.Staged != "" {
+		oldPath = absPath(res.Staged)
+	}
+	if res.NewName == "" || oldPath == newPath || res.Conflict == ConflictSkipped {
+		return nil
+	}
+	hash := res.DstHashes[l.algorithm]
+	if hash == "" {
+		hash = res.SrcHashes[l.algorithm]
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.writeLine(UndoRecord{Old: oldPath, New: newPath, Hash: hash, Replaced: res.Replaced}); err != nil {
+		return err
+	}
+	l.count++
+	return nil
+}
+
+// recordMove 记录两阶段重命名中移入或移出暂存路径的一次移动
+func (l *UndoLog) recordMove(oldPath, newPath string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.writeLine(UndoRecord{Old: absPath(oldPath), New: absPath(newPath)}); err != nil {
+		return err
+	}
+	l.count++
+	return nil
+}
+
+// writeLine 追加一行JSON（每行单独写入，崩溃时最多丢失正在写的一行）
+func (l *UndoLog) writeLine(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("写入撤销日志失败: %w", err)
+	}
+	return nil
+}
+
+// Close 落盘并关闭撤销日志
+func (l *UndoLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.file.Sync(); err != nil {
+		l.file.Close()
+		return fmt.Errorf("写入撤销日志失败: %w", err)
+	}
+	return l.file.Close()
+}
+
+// UndoStatus 撤销结果
+type UndoStatus string
+
+const (
+	UndoRestored UndoStatus = "restored" // 已恢复
+	UndoFailed   UndoStatus = "failed"   // 无法恢复
+)
+
+// UndoEntry 单条记录的撤销结果
+type UndoEntry struct {
+	UndoRecord
+	Status UndoStatus // 撤销结果
+	Err    error      // 无法恢复的原因
+}
+
+// UndoReport 撤销报告
+type UndoReport struct {
+	Mode    string      // 被撤销的批处理模式
+	Entries []UndoEntry // 按撤销顺序（与执行顺序相反）
+	Counts  map[UndoStatus]int
+	Lost    int // 执行时被覆盖、无法恢复的文件数
+	Pending int // 因取消而未处理的记录数
+}
+
+// ReadUndoLog 读取撤销日志，返回批处理模式、哈希算法和按执行顺序排列的记录
+// 崩溃时可能写了一半的最后一行会被忽略
+func ReadUndoLog(path string) (string, string, []UndoRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", "", nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	if !scanner.Scan() {
+		return "", "", nil, fmt.Errorf("撤销日志为空")
+	}
+	var header undoHeader
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil || header.Version != undoVersion {
+		return "", "", nil, fmt.Errorf("不是有效的撤销日志")
+	}
+
+	var records []UndoRecord
+	for scanner.Scan() {
+		var rec UndoRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil || rec.Old == "" || rec.New == "" {
+			continue
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return "", "", nil, err
+	}
+	return header.Mode, header.Algorithm, records, nil
+}
+
+// Undo 按与执行相反的顺序撤销批处理：每一步先校验新文件哈希，再移回原路径
+// dryRun为true时只检查、不移动文件。ctx取消时返回已处理部分的报告和ctx.Err()
+func Undo(ctx context.Context, path string, dryRun bool) (*UndoReport, error) {
+	mode, algo, records, err := ReadUndoLog(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取撤销日志失败: %w", err)
+	}
+
+	report := &UndoReport{Mode: mode, Counts: map[UndoStatus]int{}}
+	// 只检查时模拟前面各步的移动：vacated为已移走的路径，filled为已移入的路径
+	sim := &undoSim{dryRun: dryRun, vacated: map[string]bool{}, filled: map[string]bool{}}
+	for i := len(records) - 1; i >= 0; i-- {
+		if ctx.Err() != nil {
+			report.Pending = i + 1
+			return report, ctx.Err()
+		}
+		rec := records[i]
+		entry := UndoEntry{UndoRecord: rec, Status: UndoRestored}
+		if err := undoOne(ctx, rec, algo, sim); err != nil {
+			if ctx.Err() != nil {
+				// 校验或复制被取消时该文件保持原状，计入未处理
+				report.Pending = i + 1
+				return report, ctx.Err()
+			}
+			entry.Status = UndoFailed
+			entry.Err = err
+		} else if dryRun {
+			sim.vacated[rec.New], sim.filled[rec.New] = true, false
+			sim.vacated[rec.Old], sim.filled[rec.Old] = false, true
+		}
+		if rec.Replaced {
+			report.Lost++
+		}
+		report.Entries = append(report.Entries, entry)
+		report.Counts[entry.Status]++
+	}
+	return report, nil
+}
+
+// undoSim 只检查模式下模拟的文件移动
+type undoSim struct {
+	dryRun  bool
+	vacated map[string]bool
+	filled  map[string]bool
+}
+
+// lstat 返回路径在模拟移动之后的状态
+func (s *undoSim) lstat(path string) error {
+	if s.filled[path] {
+		return nil
+	}
+	if s.vacated[path] {
+		return fs.ErrNotExist
+	}
+	_, err := os.Lstat(path)
+	return err
+}
+
+// undoOne 把单个文件从新路径移回原路径
+func undoOne(ctx context.Context, rec UndoRecord, algo string, sim *undoSim) error {
+	if err := sim.lstat(rec.New); err != nil {
+		return fmt.Errorf("新文件不存在: %w", err)
+	}
+	if err := sim.lstat(rec.Old); err == nil {
+		return fmt.Errorf("原路径已存在文件，不覆盖")
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	// 模拟移入的文件内容与记录一致，无需校验
+	if rec.Hash != "" && !sim.filled[rec.New] {
+		sums, err := calculateFileHashes(ctx, rec.New, []string{algo})
+		if err != nil {
+			return fmt.Errorf("计算哈希失败: %w", err)
+		}
+		if sums[algo] != rec.Hash {
+			return fmt.Errorf("新文件内容已改变（哈希不一致），不恢复")
+		}
+	}
+	if sim.dryRun {
+		return nil
+	}
+
+	if err := createDirectory(filepath.Dir(rec.Old)); err != nil {
+		return fmt.Errorf("创建原目录失败: %w", err)
+	}
+	if err := os.Rename(rec.New, rec.Old); err != nil {
+		if !errors.Is(err, errCrossDevice) {
+			return fmt.Errorf("移回失败: %w", err)
+		}
+		// 跨文件系统：复制并校验后删除新文件
+		// 恢复原文件时尽量保留全部元数据
+		if _, err := copyAndDelete(ctx, rec.New, rec.Old, []string{algo}, SupportedPreserve(), nil); err != nil {
+			return fmt.Errorf("跨文件系统移回失败: %w", err)
+		}
+	}
+	return nil
+}
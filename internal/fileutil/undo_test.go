@@ -0,0 +1,208 @@
+package fileutil
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeUndoLog 按执行顺序写出撤销日志（哈希为新路径上当前内容的MD5）
+func writeUndoLog(t *testing.T, dir string, moves [][2]string) string {
+	t.Helper()
+	path := filepath.Join(dir, "undo.jsonl")
+	l, err := createUndoLog(path, SchedulerConfig{Mode: "rename", SrcRoot: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range moves {
+		sums, err := calculateFileHashes(context.Background(), filepath.Join(dir, m[1]), []string{HashMD5})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := UndoRecord{Old: filepath.Join(dir, m[0]), New: filepath.Join(dir, m[1]), Hash: sums[HashMD5]}
+		if err := l.writeLine(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// readDir 返回目录下的文件名和内容（不含撤销日志）
+func readDir(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Name() == "undo.jsonl" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[e.Name()] = string(data)
+	}
+	return files
+}
+
+// TestUndo 按与执行相反的顺序恢复，内容被修改、原路径被占用或新文件不存在时不恢复该文件
+func TestUndo(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  map[string]string // 执行批处理之后的文件
+		moves  [][2]string       // 执行顺序的 原路径 -> 新路径
+		before func(dir string)  // 撤销之前对文件的修改
+		dryRun bool
+		want   []UndoStatus      // 撤销顺序的结果
+		after  map[string]string // 撤销之后的文件
+	}{
+		{
+			name:  "全部恢复",
+			files: map[string]string{"x_a.txt": "A", "x_b.txt": "B"},
+			moves: [][2]string{{"a.txt", "x_a.txt"}, {"b.txt", "x_b.txt"}},
+			want:  []UndoStatus{UndoRestored, UndoRestored},
+			after: map[string]string{"a.txt": "A", "b.txt": "B"},
+		},
+		{
+			name:  "链式重命名按相反顺序恢复",
+			files: map[string]string{"b.txt": "A", "c.txt": "B"},
+			moves: [][2]string{{"b.txt", "c.txt"}, {"a.txt", "b.txt"}},
+			want:  []UndoStatus{UndoRestored, UndoRestored},
+			after: map[string]string{"a.txt": "A", "b.txt": "B"},
+		},
+		{
+			name:   "只检查时模拟前面的恢复",
+			files:  map[string]string{"b.txt": "A", "c.txt": "B"},
+			moves:  [][2]string{{"b.txt", "c.txt"}, {"a.txt", "b.txt"}},
+			dryRun: true,
+			want:   []UndoStatus{UndoRestored, UndoRestored},
+			after:  map[string]string{"b.txt": "A", "c.txt": "B"},
+		},
+		{
+			name:  "新文件内容已改变",
+			files: map[string]string{"x_a.txt": "A", "x_b.txt": "B"},
+			moves: [][2]string{{"a.txt", "x_a.txt"}, {"b.txt", "x_b.txt"}},
+			before: func(dir string) {
+				os.WriteFile(filepath.Join(dir, "x_b.txt"), []byte("changed"), 0644)
+			},
+			want:  []UndoStatus{UndoFailed, UndoRestored},
+			after: map[string]string{"a.txt": "A", "x_b.txt": "changed"},
+		},
+		{
+			name:  "原路径已被占用",
+			files: map[string]string{"x_a.txt": "A"},
+			moves: [][2]string{{"a.txt", "x_a.txt"}},
+			before: func(dir string) {
+				os.WriteFile(filepath.Join(dir, "a.txt"), []byte("other"), 0644)
+			},
+			want:  []UndoStatus{UndoFailed},
+			after: map[string]string{"a.txt": "other", "x_a.txt": "A"},
+		},
+		{
+			name:  "新文件不存在",
+			files: map[string]string{"x_a.txt": "A"},
+			moves: [][2]string{{"a.txt", "x_a.txt"}},
+			before: func(dir string) {
+				os.Remove(filepath.Join(dir, "x_a.txt"))
+			},
+			want:  []UndoStatus{UndoFailed},
+			after: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			log := writeUndoLog(t, dir, tt.moves)
+			if tt.before != nil {
+				tt.before(dir)
+			}
+
+			report, err := Undo(context.Background(), log, tt.dryRun)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []UndoStatus
+			for _, e := range report.Entries {
+				got = append(got, e.Status)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("撤销结果 = %v, 期望 %v", got, tt.want)
+			}
+			if report.Mode != "rename" {
+				t.Errorf("Mode = %s, 期望 rename", report.Mode)
+			}
+			if files := readDir(t, dir); !reflect.DeepEqual(files, tt.after) {
+				t.Errorf("撤销后的文件 = %v, 期望 %v", files, tt.after)
+			}
+		})
+	}
+}
+
+// TestUndoCanceled 取消时返回已处理部分的报告，其余记录计入未处理且文件保持原状
+func TestUndoCanceled(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{"x_a.txt": "A", "x_b.txt": "B"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	log := writeUndoLog(t, dir, [][2]string{{"a.txt", "x_a.txt"}, {"b.txt", "x_b.txt"}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report, err := Undo(ctx, log, false)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Undo() 错误 = %v, 期望 context.Canceled", err)
+	}
+	if report == nil || len(report.Entries) != 0 || report.Pending != 2 || report.Mode != "rename" {
+		t.Fatalf("Undo() 报告 = %+v, 期望未处理 2 条", report)
+	}
+	want := map[string]string{"x_a.txt": "A", "x_b.txt": "B"}
+	if files := readDir(t, dir); !reflect.DeepEqual(files, want) {
+		t.Errorf("取消后的文件 = %v, 期望 %v", files, want)
+	}
+}
+
+// TestReadUndoLog 忽略崩溃时写了一半的最后一行，拒绝没有有效首行的文件
+func TestReadUndoLog(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("A"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	log := writeUndoLog(t, dir, [][2]string{{"old.txt", "a.txt"}})
+	f, err := os.OpenFile(log, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"old":"/tmp/x","ne`)
+	f.Close()
+
+	mode, algo, records, err := ReadUndoLog(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode != "rename" || algo != HashMD5 || len(records) != 1 || records[0].New != filepath.Join(dir, "a.txt") {
+		t.Errorf("ReadUndoLog = %s, %s, %+v", mode, algo, records)
+	}
+
+	bad := filepath.Join(dir, "bad.jsonl")
+	os.WriteFile(bad, []byte("not json\n"), 0644)
+	if _, _, _, err := ReadUndoLog(bad); err == nil {
+		t.Error("无效的撤销日志应返回错误")
+	}
+}
@@ -0,0 +1,178 @@
+package fileutil
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// streamQueueSize 流式扫描时待处理文件通道的容量：Worker处理不过来时扫描暂停，
+// 内存占用与目录中的文件数无关
+const streamQueueSize = 1024
+
+// walkFiles 遍历目录，对每个普通文件调用fn（忽略复制中的临时文件、暂存文件、exclude中的文件和未通过filter筛选的文件，
+// 整个被filter排除的目录不再进入），返回遍历中无法读取的路径数；fn返回fs.SkipAll时停止遍历，返回其他错误时停止并返回该错误
+func walkFiles(root string, exclude map[string]bool, filter *ScanFilter, fn func(path string) error) (int, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return 0, err
+	}
+	if !info.IsDir() {
+		return 0, fmt.Errorf("%s 不是目录", root)
+	}
+
+	unreadable := 0
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			unreadable++
+			return nil
+		}
+		if d.IsDir() {
+			if filter != nil && path != root {
+				if rel, err := filepath.Rel(root, path); err == nil && filter.skipDir(rel) {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+		if isTempFile(d.Name()) || isStagedFile(d.Name()) {
+			return nil
+		}
+		if len(exclude) > 0 {
+			if abs, err := filepath.Abs(path); err == nil && exclude[abs] {
+				return nil
+			}
+		}
+		if filter != nil {
+			// 只有需要筛选时才读取文件信息
+			info, err := d.Info()
+			if err != nil {
+				unreadable++
+				return nil
+			}
+			if rel, err := filepath.Rel(root, path); err == nil && !filter.check(rel, info) {
+				return nil
+			}
+		}
+		return fn(path)
+	})
+	return unreadable, err
+}
+
+// scanFiles 遍历目录，返回所有待处理的文件路径以及遍历中无法读取的路径数；filter为空时不筛选
+func scanFiles(root string, exclude map[string]bool, filter *ScanFilter) ([]string, int, error) {
+	var files []string
+	unreadable, err := walkFiles(root, exclude, filter, func(path string) error {
+		files = append(files, path)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return files, unreadable, nil
+}
+
+// withinDir 判断path是否为root本身或位于root之下
+func withinDir(path, root string) bool {
+	rel, err := filepath.Rel(absPath(root), absPath(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// streams 判断能否边扫描边处理。以下情况需要先得到完整的文件列表：
+// 指定了文件列表或执行计划、需要按排序分配序号、在源目录内原地重命名（需要预先检测互换，
+// 且改名后的文件可能再次被扫描到）、输出目录位于源目录之内（输出文件可能再次被扫描到）
+func (s *Scheduler) streams(op Operator) bool {
+	if len(s.cfg.Files) > 0 || s.template != nil || s.cfg.Numbering != nil || stagesSwaps(op.Info()) {
+		return false
+	}
+	return s.cfg.DestRoot == "" || !withinDir(s.cfg.DestRoot, s.cfg.SrcRoot)
+}
+
+// walkPending 返回遍历源目录中待处理文件的函数（断点续作时跳过已完成的文件），供边扫描边处理前统计大小做空间预检；
+// 使用单独编译的筛选条件，不影响Summary.Filtered的统计
+func (s *Scheduler) walkPending(ctx context.Context, exclude map[string]bool) func(fn func(path string) error) error {
+	return func(fn func(path string) error) error {
+		var filter *ScanFilter
+		if s.filter != nil {
+			var err error
+			if filter, err = s.cfg.Filter.Compile(s.cfg.SrcRoot); err != nil {
+				return err
+			}
+		}
+		_, err := walkFiles(s.cfg.SrcRoot, exclude, filter, func(path string) error {
+			if s.journal != nil && s.cfg.Resume && s.journal.completed(ctx, path) {
+				return nil
+			}
+			return fn(path)
+		})
+		return err
+	}
+}
+
+// streamFiles 在后台遍历源目录，把待处理文件送入有界通道（断点续作时跳过已完成的文件），
+// 扫描过程中Summary.Total随发现的文件增加；找到第一个文件或扫描结束后返回
+func (s *Scheduler) streamFiles(ctx context.Context, exclude map[string]bool) (<-chan string, error) {
+	paths := make(chan string, streamQueueSize)
+	ready := make(chan struct{})
+	go func() {
+		defer close(paths)
+		found := false
+		unreadable, err := walkFiles(s.cfg.SrcRoot, exclude, s.filter, func(path string) error {
+			if s.resumed(ctx, path) {
+				s.mu.Lock()
+				s.summary.Resumed++
+				s.mu.Unlock()
+				return nil
+			}
+			if s.cfg.Delete {
+				// 删除多余文件需要完整的源文件列表（只在扫描goroutine中追加，批处理结束后读取）
+				s.scanned = append(s.scanned, path)
+			}
+			s.mu.Lock()
+			s.summary.Total++
+			s.mu.Unlock()
+			if !found {
+				found = true
+				close(ready)
+			}
+			select {
+			case paths <- path:
+				return nil
+			case <-ctx.Done():
+				return fs.SkipAll
+			}
+		})
+
+		s.mu.Lock()
+		s.scanErrs = unreadable
+		s.scanErr = err
+		s.scanning = false
+		if s.filter != nil && len(s.filter.Counts()) > 0 {
+			s.summary.Filtered = make(map[FilterReason]int, len(s.filter.Counts()))
+			for r, n := range s.filter.Counts() {
+				s.summary.Filtered[r] = n
+			}
+		}
+		s.mu.Unlock()
+		if !found {
+			close(ready)
+		}
+	}()
+
+	<-ready
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case s.summary.Total > 0 || s.summary.Resumed > 0:
+		// 找到了待处理的文件，或全部已在上次完成（此时没有任务需要执行）
+		return paths, nil
+	case s.scanErr != nil:
+		return nil, fmt.Errorf("扫描源目录失败: %w", s.scanErr)
+	case len(s.summary.Filtered) > 0:
+		return nil, fmt.Errorf("源目录中没有符合筛选条件的文件（已排除: %s）", FormatFiltered(s.summary.Filtered))
+	}
+	return nil, fmt.Errorf("源目录中没有找到文件")
+}
@@ -1,10 +1,12 @@
 package ui
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"os"
 	"path/filepath"
-	"sync"
+	"strconv"
+	"strings"
 	"time"
 
 	"training-practice/internal/fileutil"
@@ -16,6 +18,9 @@ import (
 	"fyne.io/fyne/v2/widget"
 )
 
+// renamePreviewCount 重命名预览显示的文件数
+const renamePreviewCount = 5
+
 // Run 启动文件处理工具的UI界面
 func Run() {
 	myApp := app.New()
@@ -34,16 +39,231 @@ func Run() {
 	prefixEntry.SetPlaceHolder("重命名加前缀（可选）...")
 	suffixEntry := widget.NewEntry()
 	suffixEntry.SetPlaceHolder("重命名加后缀（可选）...")
-
-	// 操作模式选择
-	modeRadio := widget.NewRadioGroup([]string{
-		"计算MD5",
-		"重命名",
-		"复制",
-		"复制+重命名",
-		"移动",
+	templateEntry := widget.NewEntry()
+	templateEntry.SetPlaceHolder("重命名模板（可选，替代前缀/后缀），如 {{pad .Seq 3}}_{{.Name}}{{.Ext}}")
+	regexEntry := widget.NewEntry()
+	regexEntry.SetPlaceHolder("正则表达式（可选），如 IMG_(\\d+)")
+	replaceEntry := widget.NewEntry()
+	replaceEntry.SetPlaceHolder("替换为，如 photo-$1")
+	regexIgnoreCaseCheck := widget.NewCheck("忽略大小写", nil)
+	regexAllCheck := widget.NewCheck("替换所有匹配", nil)
+	regexScopes := map[string]fileutil.RegexScope{}
+	var regexScopeOptions []string
+	for _, scope := range fileutil.RegexScopes() {
+		regexScopes[scope.Label()] = scope
+		regexScopeOptions = append(regexScopeOptions, scope.Label())
+	}
+	regexScopeSelect := widget.NewSelect(regexScopeOptions, nil)
+	regexScopeSelect.SetSelected(fileutil.RegexScopeName.Label())
+	// regexRule 根据界面设置生成正则规则（未填写正则表达式时为nil）
+	regexRule := func() *fileutil.RegexRule {
+		if regexEntry.Text == "" {
+			return nil
+		}
+		return &fileutil.RegexRule{
+			Pattern:    regexEntry.Text,
+			Replace:    replaceEntry.Text,
+			IgnoreCase: regexIgnoreCaseCheck.Checked,
+			All:        regexAllCheck.Checked,
+			Scope:      regexScopes[regexScopeSelect.Selected],
+		}
+	}
+	// 文件名变换（按 规范化 -> ASCII -> 大小写 -> 空白 -> 非法字符 -> 截断 的顺序执行）
+	normSelect := widget.NewSelect([]string{"不规范化", "NFC", "NFD"}, nil)
+	normSelect.SetSelected("不规范化")
+	caseTransforms := map[string]string{
+		fileutil.TransformLabel(fileutil.TransformLower): fileutil.TransformLower,
+		fileutil.TransformLabel(fileutil.TransformUpper): fileutil.TransformUpper,
+		fileutil.TransformLabel(fileutil.TransformTitle): fileutil.TransformTitle,
+	}
+	caseSelect := widget.NewSelect([]string{
+		"大小写不变",
+		fileutil.TransformLabel(fileutil.TransformLower),
+		fileutil.TransformLabel(fileutil.TransformUpper),
+		fileutil.TransformLabel(fileutil.TransformTitle),
 	}, nil)
-	modeRadio.SetSelected("计算MD5")
+	caseSelect.SetSelected("大小写不变")
+	asciiCheck := widget.NewCheck(fileutil.TransformLabel(fileutil.TransformASCII), nil)
+	underscoreCheck := widget.NewCheck(fileutil.TransformLabel(fileutil.TransformUnderscore), nil)
+	stripIllegalCheck := widget.NewCheck(fileutil.TransformLabel(fileutil.TransformStripIllegal), nil)
+	truncateEntry := widget.NewEntry()
+	truncateEntry.SetPlaceHolder("截断到N字节（可选）")
+	// transformSpecs 根据界面设置生成文件名变换链
+	transformSpecs := func() []string {
+		var specs []string
+		switch normSelect.Selected {
+		case "NFC":
+			specs = append(specs, fileutil.TransformNFC)
+		case "NFD":
+			specs = append(specs, fileutil.TransformNFD)
+		}
+		if asciiCheck.Checked {
+			specs = append(specs, fileutil.TransformASCII)
+		}
+		if t, ok := caseTransforms[caseSelect.Selected]; ok {
+			specs = append(specs, t)
+		}
+		if underscoreCheck.Checked {
+			specs = append(specs, fileutil.TransformUnderscore)
+		}
+		if stripIllegalCheck.Checked {
+			specs = append(specs, fileutil.TransformStripIllegal)
+		}
+		if n := strings.TrimSpace(truncateEntry.Text); n != "" {
+			specs = append(specs, fileutil.TransformTruncate+":"+n)
+		}
+		return specs
+	}
+	// 序号重命名（先排序再分配序号，与模板同时使用时只决定Seq/Num的值）
+	numberCheck := widget.NewCheck("按序号重命名（前缀+序号+后缀）", nil)
+	sortModes := map[string]string{}
+	var sortOptions []string
+	for _, mode := range fileutil.SortModes() {
+		sortModes[fileutil.SortModeLabel(mode)] = mode
+		sortOptions = append(sortOptions, fileutil.SortModeLabel(mode))
+	}
+	sortSelect := widget.NewSelect(sortOptions, nil)
+	sortSelect.SetSelected(fileutil.SortModeLabel(fileutil.SortByName))
+	numberStartEntry := widget.NewEntry()
+	numberStartEntry.SetPlaceHolder("起始序号（默认1）")
+	numberStepEntry := widget.NewEntry()
+	numberStepEntry.SetPlaceHolder("步长（默认1）")
+	numberPadEntry := widget.NewEntry()
+	numberPadEntry.SetPlaceHolder("补零位数（默认自动）")
+	// numbering 根据界面设置生成序号设置（未勾选时为nil）
+	numbering := func() (*fileutil.Numbering, error) {
+		if !numberCheck.Checked {
+			return nil, nil
+		}
+		n := fileutil.DefaultNumbering()
+		n.Sort = sortModes[sortSelect.Selected]
+		for _, field := range []struct {
+			entry *widget.Entry
+			value *int
+			name  string
+		}{
+			{numberStartEntry, &n.Start, "起始序号"},
+			{numberStepEntry, &n.Step, "序号步长"},
+			{numberPadEntry, &n.Pad, "补零位数"},
+		} {
+			text := strings.TrimSpace(field.entry.Text)
+			if text == "" {
+				continue
+			}
+			v, err := strconv.Atoi(text)
+			if err != nil {
+				return nil, fmt.Errorf("%s必须是整数: %s", field.name, text)
+			}
+			*field.value = v
+		}
+		return &n, nil
+	}
+	// 扫描筛选（包含/排除通配符、大小、修改时间、隐藏文件、忽略文件）
+	includeEntry := widget.NewEntry()
+	includeEntry.SetPlaceHolder("包含（逗号分隔，可选），如 *.jpg,*.png")
+	excludeEntry := widget.NewEntry()
+	excludeEntry.SetPlaceHolder("排除（逗号分隔，可选），如 *.log,**/cache/**")
+	minSizeEntry := widget.NewEntry()
+	minSizeEntry.SetPlaceHolder("最小大小，如 10K")
+	maxSizeEntry := widget.NewEntry()
+	maxSizeEntry.SetPlaceHolder("最大大小，如 100M")
+	afterEntry := widget.NewEntry()
+	afterEntry.SetPlaceHolder("修改时间不早于，如 2024-01-31 或 7d")
+	beforeEntry := widget.NewEntry()
+	beforeEntry.SetPlaceHolder("修改时间早于，如 2024-12-31")
+	skipHiddenCheck := widget.NewCheck("跳过隐藏文件和目录", nil)
+	ignoreFileEntry := widget.NewEntry()
+	ignoreFileEntry.SetPlaceHolder("忽略文件（.gitignore格式，相对源目录），如 .gitignore")
+	// splitList 拆分逗号分隔的列表，忽略空项
+	splitList := func(text string) []string {
+		var items []string
+		for _, item := range strings.Split(text, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items
+	}
+	// scanFilter 根据界面设置生成扫描筛选条件（未设置任何条件时为nil）
+	scanFilter := func() (*fileutil.Filter, error) {
+		filter := &fileutil.Filter{
+			Include:    splitList(includeEntry.Text),
+			Exclude:    splitList(excludeEntry.Text),
+			SkipHidden: skipHiddenCheck.Checked,
+			IgnoreFile: strings.TrimSpace(ignoreFileEntry.Text),
+		}
+		var err error
+		if text := strings.TrimSpace(minSizeEntry.Text); text != "" {
+			if filter.MinSize, err = fileutil.ParseSize(text); err != nil {
+				return nil, err
+			}
+		}
+		if text := strings.TrimSpace(maxSizeEntry.Text); text != "" {
+			if filter.MaxSize, err = fileutil.ParseSize(text); err != nil {
+				return nil, err
+			}
+		}
+		now := time.Now()
+		if text := strings.TrimSpace(afterEntry.Text); text != "" {
+			if filter.ModifiedAfter, err = fileutil.ParseTimeBound(text, now); err != nil {
+				return nil, err
+			}
+		}
+		if text := strings.TrimSpace(beforeEntry.Text); text != "" {
+			if filter.ModifiedBefore, err = fileutil.ParseTimeBound(text, now); err != nil {
+				return nil, err
+			}
+		}
+		if filter.IsZero() {
+			return nil, nil
+		}
+		return filter, nil
+	}
+	// showFilter 把已保存的筛选条件填入界面
+	showFilter := func(filter *fileutil.Filter) {
+		formatSize := func(n int64) string {
+			if n == 0 {
+				return ""
+			}
+			return strconv.FormatInt(n, 10)
+		}
+		formatTime := func(t time.Time) string {
+			if t.IsZero() {
+				return ""
+			}
+			return t.Local().Format("2006-01-02 15:04:05")
+		}
+		includeEntry.SetText(strings.Join(filter.Include, ","))
+		excludeEntry.SetText(strings.Join(filter.Exclude, ","))
+		minSizeEntry.SetText(formatSize(filter.MinSize))
+		maxSizeEntry.SetText(formatSize(filter.MaxSize))
+		afterEntry.SetText(formatTime(filter.ModifiedAfter))
+		beforeEntry.SetText(formatTime(filter.ModifiedBefore))
+		skipHiddenCheck.SetChecked(filter.SkipHidden)
+		ignoreFileEntry.SetText(filter.IgnoreFile)
+	}
+
+	renamePreviewLabel := widget.NewLabel("")
+	renamePreviewLabel.Wrapping = fyne.TextWrapWord
+
+	// 操作模式选择（枚举已注册的文件操作）
+	operatorsByLabel := map[string]fileutil.Operator{}
+	var modeLabels []string
+	for _, op := range fileutil.Operators() {
+		info := op.Info()
+		operatorsByLabel[info.Label] = op
+		modeLabels = append(modeLabels, info.Label)
+	}
+	modeRadio := widget.NewRadioGroup(modeLabels, nil)
+	modeRadio.SetSelected(modeLabels[0])
+
+	// 哈希算法选择（可多选，一次读取同时计算）
+	algorithmCheck := widget.NewCheckGroup(fileutil.HashAlgorithms(), nil)
+	algorithmCheck.Horizontal = true
+	algorithmCheck.SetSelected([]string{fileutil.DefaultAlgorithm})
+
+	// 校验清单（仅计算哈希模式，写入源目录）
+	manifestCheck := widget.NewCheck("生成校验清单（md5sum/sha256sum格式，写入源目录）", nil)
 
 	// 异常策略设置
 	errorPolicySelect := widget.NewSelect([]string{
@@ -70,30 +290,34 @@ func Run() {
 		retryIntervalLabel.SetText(fmt.Sprintf("%.0f秒", v))
 	}
 
-	// 错误类型策略表格
-	errorTypes := []string{
-		"文件不存在",
-		"权限不足",
-		"磁盘空间不足",
-		"读取错误",
-		"写入错误",
-		"跨设备错误",
-		"未知错误",
+	// 重试预算：所有文件合计的重试等待时间上限
+	retryBudgets := map[string]time.Duration{
+		"不限":   0,
+		"1分钟":  time.Minute,
+		"5分钟":  5 * time.Minute,
+		"10分钟": 10 * time.Minute,
+		"30分钟": 30 * time.Minute,
 	}
+	retryBudgetSelect := widget.NewSelect([]string{"不限", "1分钟", "5分钟", "10分钟", "30分钟"}, nil)
+	retryBudgetSelect.SetSelected("10分钟")
+
+	// 错误类型策略表格（按错误类型列出，默认值取自错误处理器）
+	errorTypes := fileutil.ErrorTypes()
+	defaultHandler := fileutil.NewErrorHandler()
 
 	errorPolicyOptions := []string{"跳过", "重试", "终止"}
+	policyLabels := map[fileutil.ErrorPolicy]string{
+		fileutil.PolicySkip:  "跳过",
+		fileutil.PolicyRetry: "重试",
+		fileutil.PolicyAbort: "终止",
+	}
 
 	var errorPolicyWidgets []*widget.Select
 	errorPolicyGrid := container.NewGridWithColumns(2)
 	for _, errorType := range errorTypes {
-		label := widget.NewLabel(errorType)
+		label := widget.NewLabel(errorType.Label())
 		policySelect := widget.NewSelect(errorPolicyOptions, nil)
-		policySelect.SetSelected("重试")
-		if errorType == "文件不存在" {
-			policySelect.SetSelected("跳过")
-		} else if errorType == "磁盘空间不足" {
-			policySelect.SetSelected("终止")
-		}
+		policySelect.SetSelected(policyLabels[defaultHandler.Policy(errorType)])
 		errorPolicyWidgets = append(errorPolicyWidgets, policySelect)
 		errorPolicyGrid.Add(label)
 		errorPolicyGrid.Add(policySelect)
@@ -103,9 +327,27 @@ func Run() {
 	renameGroup := container.NewBorder(
 		widget.NewLabelWithStyle("重命名设置", fyne.TextAlignLeading, fyne.TextStyle{}),
 		nil, nil, nil,
-		container.NewGridWithColumns(2,
-			container.NewVBox(widget.NewLabel("前缀:"), prefixEntry),
-			container.NewVBox(widget.NewLabel("后缀:"), suffixEntry),
+		container.NewVBox(
+			container.NewGridWithColumns(2,
+				container.NewVBox(widget.NewLabel("前缀:"), prefixEntry),
+				container.NewVBox(widget.NewLabel("后缀:"), suffixEntry),
+			),
+			container.NewVBox(widget.NewLabel("模板:"), templateEntry),
+			container.NewVBox(
+				container.NewHBox(numberCheck, sortSelect),
+				container.NewGridWithColumns(3, numberStartEntry, numberStepEntry, numberPadEntry),
+			),
+			container.NewVBox(
+				widget.NewLabel("正则替换（不匹配的文件将被跳过）:"),
+				container.NewGridWithColumns(2, regexEntry, replaceEntry),
+				container.NewHBox(regexScopeSelect, regexIgnoreCaseCheck, regexAllCheck),
+			),
+			container.NewVBox(
+				widget.NewLabel("文件名变换:"),
+				container.NewGridWithColumns(3, normSelect, caseSelect, truncateEntry),
+				container.NewHBox(asciiCheck, underscoreCheck, stripIllegalCheck),
+			),
+			renamePreviewLabel,
 		),
 	)
 
@@ -115,6 +357,66 @@ func Run() {
 	destPathLabel.Truncation = fyne.TextTruncateEllipsis
 	destGroupContainer := container.NewVBox()
 
+	// 严格校验（复制后重新读取目标文件）
+	verifyCheck := widget.NewCheck("严格校验（复制后重新读取目标文件比对哈希）", nil)
+
+	// 复制时保留的元数据（只列出当前平台支持的项）
+	preserveAttrs := map[string]fileutil.MetaAttr{}
+	var preserveOptions, preserveDefaults []string
+	for _, a := range fileutil.SupportedPreserve() {
+		preserveAttrs[a.Label()] = a
+		preserveOptions = append(preserveOptions, a.Label())
+		if fileutil.DefaultPreserve.Has(a) {
+			preserveDefaults = append(preserveDefaults, a.Label())
+		}
+	}
+	preserveCheck := widget.NewCheckGroup(preserveOptions, nil)
+	preserveCheck.Horizontal = true
+	preserveCheck.SetSelected(preserveDefaults)
+
+	// 同步设置（仅同步模式显示）
+	compareModes := map[string]string{
+		"大小+修改时间": fileutil.CompareSizeMtime,
+		"完整哈希":    fileutil.CompareHash,
+	}
+	compareSelect := widget.NewSelect([]string{"大小+修改时间", "完整哈希"}, nil)
+	compareSelect.SetSelected("大小+修改时间")
+	deleteCheck := widget.NewCheck("删除目标目录中源目录已不存在的文件", nil)
+	syncGroup := container.NewVBox(
+		container.NewBorder(nil, nil, widget.NewLabel("比较方式:"), nil, compareSelect),
+		deleteCheck,
+	)
+
+	// 目标已存在时的冲突策略（同步模式按比较方式处理，不显示）
+	conflictPolicies := map[string]fileutil.ConflictPolicy{}
+	var conflictOptions []string
+	for _, p := range fileutil.ConflictPolicies() {
+		conflictPolicies[p.Label()] = p
+		conflictOptions = append(conflictOptions, p.Label())
+	}
+	conflictSelect := widget.NewSelect(conflictOptions, nil)
+	conflictSelect.SetSelected(fileutil.DefaultConflictPolicy.Label())
+	conflictGroup := container.NewBorder(nil, nil, widget.NewLabel("目标已存在时:"),
+		widget.NewLabel("默认跳过已有文件，不再覆盖"), conflictSelect)
+
+	// 磁盘空间检查（仅需要目标目录的模式显示）
+	spaceChecks := map[string]string{
+		"空间不足时拒绝启动": fileutil.SpaceCheckFail,
+		"空间不足时只提示":  fileutil.SpaceCheckWarn,
+		"不检查":       fileutil.SpaceCheckOff,
+	}
+	spaceCheckSelect := widget.NewSelect([]string{"空间不足时拒绝启动", "空间不足时只提示", "不检查"}, nil)
+	spaceCheckSelect.SetSelected("空间不足时拒绝启动")
+	spaceMarginEntry := widget.NewEntry()
+	spaceMarginEntry.SetText(fileutil.FormatBytes(fileutil.DefaultSpaceMargin))
+	spaceGroup := container.NewGridWithColumns(2,
+		container.NewBorder(nil, nil, widget.NewLabel("磁盘空间:"), nil, spaceCheckSelect),
+		container.NewBorder(nil, nil, widget.NewLabel("保留空间:"), nil, spaceMarginEntry),
+	)
+
+	// 断点续作（断点文件始终写入用户缓存目录）
+	resumeCheck := widget.NewCheck("断点续作（跳过上次已完成的文件，重新处理失败的文件）", nil)
+
 	// 并发设置
 	workerCountLabel := widget.NewLabel("4")
 	workerCountLabel.Alignment = fyne.TextAlignCenter
@@ -139,8 +441,7 @@ func Run() {
 	var selectedSrcDir string
 	var selectedDestDir string
 	var errorHandler *fileutil.ErrorHandler
-	var abortFlag bool
-	var abortMutex sync.Mutex
+	var running *fileutil.Scheduler
 
 	// 更新日志辅助函数
 	updateLog := func(text string) {
@@ -149,29 +450,104 @@ func Run() {
 	}
 
 	// 更新统计信息
-	updateStats := func(success, skipped, failed, total int) {
-		statsLabel.SetText(fmt.Sprintf("进度: %d/%d | 成功: %d | 跳过: %d | 失败: %d",
+	updateStats := func(success, skipped, failed int, total string) {
+		statsLabel.SetText(fmt.Sprintf("进度: %d/%s | 成功: %d | 跳过: %d | 失败: %d",
 			success+skipped+failed, total, success, skipped, failed))
 	}
 
+	// 重命名预览：前缀/后缀/模板修改或选择源目录后，显示前几个文件的新名称
+	updateRenamePreview := func() {
+		if selectedSrcDir == "" {
+			renamePreviewLabel.SetText("选择源文件夹后可预览新名称")
+			return
+		}
+		n, err := numbering()
+		if err != nil {
+			renamePreviewLabel.SetText(fmt.Sprintf("预览失败: %v", err))
+			return
+		}
+		filter, err := scanFilter()
+		if err != nil {
+			renamePreviewLabel.SetText(fmt.Sprintf("预览失败: %v", err))
+			return
+		}
+		previews, err := fileutil.PreviewRename(context.Background(), fileutil.SchedulerConfig{
+			SrcRoot:    selectedSrcDir,
+			Prefix:     prefixEntry.Text,
+			Suffix:     suffixEntry.Text,
+			Template:   templateEntry.Text,
+			Regex:      regexRule(),
+			Transforms: transformSpecs(),
+			Numbering:  n,
+			Filter:     filter,
+			Mode:       "rename",
+			Algorithms: algorithmCheck.Selected,
+		}, renamePreviewCount)
+		if err != nil {
+			renamePreviewLabel.SetText(fmt.Sprintf("预览失败: %v", err))
+			return
+		}
+		var lines []string
+		for _, p := range previews {
+			if errors.Is(p.Err, fileutil.ErrNoMatch) {
+				lines = append(lines, fmt.Sprintf("%s （不匹配，跳过）", p.Old))
+			} else if p.Err != nil {
+				lines = append(lines, fmt.Sprintf("%s → 错误: %v", p.Old, p.Err))
+			} else {
+				lines = append(lines, fmt.Sprintf("%s → %s", p.Old, p.New))
+			}
+		}
+		renamePreviewLabel.SetText("预览:\n" + strings.Join(lines, "\n"))
+	}
+	prefixEntry.OnChanged = func(string) { updateRenamePreview() }
+	suffixEntry.OnChanged = func(string) { updateRenamePreview() }
+	templateEntry.OnChanged = func(string) { updateRenamePreview() }
+	regexEntry.OnChanged = func(string) { updateRenamePreview() }
+	replaceEntry.OnChanged = func(string) { updateRenamePreview() }
+	regexIgnoreCaseCheck.OnChanged = func(bool) { updateRenamePreview() }
+	regexAllCheck.OnChanged = func(bool) { updateRenamePreview() }
+	regexScopeSelect.OnChanged = func(string) { updateRenamePreview() }
+	normSelect.OnChanged = func(string) { updateRenamePreview() }
+	caseSelect.OnChanged = func(string) { updateRenamePreview() }
+	truncateEntry.OnChanged = func(string) { updateRenamePreview() }
+	asciiCheck.OnChanged = func(bool) { updateRenamePreview() }
+	underscoreCheck.OnChanged = func(bool) { updateRenamePreview() }
+	stripIllegalCheck.OnChanged = func(bool) { updateRenamePreview() }
+	numberCheck.OnChanged = func(bool) { updateRenamePreview() }
+	sortSelect.OnChanged = func(string) { updateRenamePreview() }
+	numberStartEntry.OnChanged = func(string) { updateRenamePreview() }
+	numberStepEntry.OnChanged = func(string) { updateRenamePreview() }
+	numberPadEntry.OnChanged = func(string) { updateRenamePreview() }
+	includeEntry.OnChanged = func(string) { updateRenamePreview() }
+	excludeEntry.OnChanged = func(string) { updateRenamePreview() }
+	minSizeEntry.OnChanged = func(string) { updateRenamePreview() }
+	maxSizeEntry.OnChanged = func(string) { updateRenamePreview() }
+	afterEntry.OnChanged = func(string) { updateRenamePreview() }
+	beforeEntry.OnChanged = func(string) { updateRenamePreview() }
+	skipHiddenCheck.OnChanged = func(bool) { updateRenamePreview() }
+	ignoreFileEntry.OnChanged = func(string) { updateRenamePreview() }
+	updateRenamePreview()
+
 	// --- 核心处理逻辑 ---
-	startProcess := func() {
+	// 根据界面设置生成调度配置，参数不完整时提示错误并返回false
+	buildConfig := func() (fileutil.SchedulerConfig, bool) {
 		if selectedSrcDir == "" {
 			dialog.ShowError(fmt.Errorf("请先选择源文件夹"), myWindow)
-			return
+			return fileutil.SchedulerConfig{}, false
 		}
 
-		mode := modeRadio.Selected
-		if mode == "" {
+		op, ok := operatorsByLabel[modeRadio.Selected]
+		if !ok {
 			dialog.ShowError(fmt.Errorf("请选择操作模式"), myWindow)
-			return
+			return fileutil.SchedulerConfig{}, false
 		}
+		info := op.Info()
 
 		// 检查目标目录
-		if mode == "复制" || mode == "复制+重命名" || mode == "移动" {
+		if info.NeedsDest {
 			if selectedDestDir == "" {
 				dialog.ShowError(fmt.Errorf("请先选择目标文件夹"), myWindow)
-				return
+				return fileutil.SchedulerConfig{}, false
 			}
 		}
 
@@ -179,6 +555,35 @@ func Run() {
 		errorHandler = fileutil.NewErrorHandler()
 		errorHandler.SetMaxRetries(int(maxRetriesSlider.Value))
 		errorHandler.SetRetryInterval(time.Duration(retryIntervalSlider.Value) * time.Second)
+		errorHandler.SetRetryBudget(fileutil.RetryBudget{MaxWait: retryBudgets[retryBudgetSelect.Selected]})
+		errorHandler.SetTripFunc(func(ctx context.Context, trip fileutil.Trip) fileutil.TripDecision {
+			// 熔断：所有Worker已暂停，弹窗询问继续、跳过此类错误还是终止
+			updateLog(fmt.Sprintf("\n⚠️ 熔断: %s\n所有Worker已暂停，等待选择...\n", trip))
+			choice := make(chan fileutil.TripDecision, 1)
+			var tripDialog dialog.Dialog
+			buttons := container.NewHBox()
+			for _, decision := range fileutil.TripDecisions() {
+				buttons.Add(widget.NewButton(decision.Label(), func() {
+					select {
+					case choice <- decision:
+					default:
+					}
+					tripDialog.Hide()
+				}))
+			}
+			tripDialog = dialog.NewCustomWithoutButtons("批处理已暂停",
+				container.NewVBox(widget.NewLabel(trip.String()), buttons), myWindow)
+			tripDialog.Show()
+
+			select {
+			case decision := <-choice:
+				updateLog(fmt.Sprintf("已选择: %s\n", decision.Label()))
+				return decision
+			case <-ctx.Done():
+				tripDialog.Hide()
+				return fileutil.TripAbort
+			}
+		})
 
 		// 设置错误策略
 		policyMap := map[string]fileutil.ErrorPolicy{
@@ -188,146 +593,118 @@ func Run() {
 		}
 
 		for i, errorType := range errorTypes {
-			policy := errorPolicyWidgets[i].Selected
-			if policy != "" {
-				var et fileutil.ErrorType
-				switch errorType {
-				case "文件不存在":
-					et = fileutil.ErrorFileNotFound
-				case "权限不足":
-					et = fileutil.ErrorPermissionDenied
-				case "磁盘空间不足":
-					et = fileutil.ErrorDiskSpaceFull
-				case "读取错误":
-					et = fileutil.ErrorIORead
-				case "写入错误":
-					et = fileutil.ErrorIOWrite
-				case "跨设备错误":
-					et = fileutil.ErrorCrossDevice
-				case "未知错误":
-					et = fileutil.ErrorUnknown
-				}
-				errorHandler.SetPolicy(et, policyMap[policy])
+			if policy := errorPolicyWidgets[i].Selected; policy != "" {
+				errorHandler.SetPolicy(errorType, policyMap[policy])
 			}
 		}
 
-		// 重置中止标志
-		abortMutex.Lock()
-		abortFlag = false
-		abortMutex.Unlock()
+		numberingCfg, err := numbering()
+		if err != nil {
+			dialog.ShowError(err, myWindow)
+			return fileutil.SchedulerConfig{}, false
+		}
+		filter, err := scanFilter()
+		if err != nil {
+			dialog.ShowError(err, myWindow)
+			return fileutil.SchedulerConfig{}, false
+		}
 
-		logEntry.SetText("")
-		updateLog("开始扫描并处理...\n")
-		updateLog(fmt.Sprintf("异常策略: %s\n", errorPolicySelect.Selected))
-		updateLog(fmt.Sprintf("最大重试次数: %d\n", int(maxRetriesSlider.Value)))
-		updateLog(fmt.Sprintf("重试间隔: %.0f秒\n", retryIntervalSlider.Value))
+		spaceMargin, err := fileutil.ParseSize(spaceMarginEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, myWindow)
+			return fileutil.SchedulerConfig{}, false
+		}
 
-		startTime := time.Now()
+		// 校验清单使用第一个选中的算法
+		manifestPath := ""
+		if manifestCheck.Checked && info.Name == "md5" {
+			algo := fileutil.DefaultAlgorithm
+			if len(algorithmCheck.Selected) > 0 {
+				algo = algorithmCheck.Selected[0]
+			}
+			manifestPath = filepath.Join(selectedSrcDir, fileutil.DefaultManifestName(algo))
+		}
 
-		// 扫描所有文件
-		var files []string
-		filepath.Walk(selectedSrcDir, func(path string, info os.FileInfo, err error) error {
-			if err != nil || info == nil || info.IsDir() {
-				return nil
+		cfg := fileutil.SchedulerConfig{
+			SrcRoot:      selectedSrcDir,
+			DestRoot:     selectedDestDir,
+			Prefix:       prefixEntry.Text,
+			Suffix:       suffixEntry.Text,
+			Template:     templateEntry.Text,
+			Regex:        regexRule(),
+			Transforms:   transformSpecs(),
+			Numbering:    numberingCfg,
+			Filter:       filter,
+			Mode:         info.Name,
+			Workers:      int(workerSlider.Value),
+			ErrorHandler: errorHandler,
+			StrictVerify: verifyCheck.Checked,
+			Algorithms:   algorithmCheck.Selected,
+			ManifestPath: manifestPath,
+			Compare:      compareModes[compareSelect.Selected],
+			Delete:       deleteCheck.Checked && info.Name == "sync",
+			Resume:       resumeCheck.Checked,
+			OnConflict:   conflictPolicies[conflictSelect.Selected],
+			SpaceCheck:   spaceChecks[spaceCheckSelect.Selected],
+			SpaceMargin:  spaceMargin,
+			Preserve:     fileutil.Preserve{},
+		}
+		for _, label := range preserveCheck.Selected {
+			cfg.Preserve = append(cfg.Preserve, preserveAttrs[label])
+		}
+		journalPath, err := fileutil.DefaultJournalPath(cfg)
+		if err != nil {
+			dialog.ShowError(err, myWindow)
+			return fileutil.SchedulerConfig{}, false
+		}
+		cfg.JournalPath = journalPath
+		if info.Destructive {
+			if cfg.UndoPath, err = fileutil.DefaultUndoPath(info.Name); err != nil {
+				dialog.ShowError(err, myWindow)
+				return fileutil.SchedulerConfig{}, false
 			}
-			files = append(files, path)
-			return nil
-		})
+		}
+		return cfg, true
+	}
 
-		total := len(files)
-		if total == 0 {
-			dialog.ShowError(fmt.Errorf("源目录中没有找到文件"), myWindow)
+	// 启动调度器，在后台更新进度和日志
+	runScheduler := func(cfg fileutil.SchedulerConfig) {
+		if running != nil {
+			dialog.ShowError(fmt.Errorf("任务正在执行中"), myWindow)
 			return
 		}
+		scheduler := fileutil.NewScheduler(cfg)
 
-		progressBar.Max = float64(total)
-		progressBar.SetValue(0)
-
-		// 统计信息
-		successCount := 0
-		skippedCount := 0
-		failedCount := 0
-
-		// 任务/结果通道
-		tasks := make(chan fileutil.Task, total)
-		results := make(chan fileutil.Result, total)
-		var wg sync.WaitGroup
-
-		// 转换操作模式
-		var modeCode string
-		switch mode {
-		case "计算MD5":
-			modeCode = "md5"
-		case "重命名":
-			modeCode = "rename"
-		case "复制":
-			modeCode = "copy"
-		case "复制+重命名":
-			modeCode = "copy_rename"
-		case "移动":
-			modeCode = "move"
-		}
-
-		// 启动Worker Pool
-		numWorkers := int(workerSlider.Value)
-		for i := 0; i < numWorkers; i++ {
-			wg.Add(1)
-			go func() { // 移除参数i
-				defer wg.Done()
-				for t := range tasks {
-					// 检查是否中止
-					abortMutex.Lock()
-					if abortFlag {
-						abortMutex.Unlock()
-						return
-					}
-					abortMutex.Unlock()
-
-					// 使用带重试的处理
-					res := fileutil.ProcessFileWithRetry(t,
-						int(maxRetriesSlider.Value),
-						time.Duration(retryIntervalSlider.Value)*time.Second,
-						errorHandler.HandleError)
+		logEntry.SetText("")
+		updateLog("开始扫描并处理...\n")
+		updateLog(fmt.Sprintf("异常策略: %s\n", errorPolicySelect.Selected))
+		updateLog(fmt.Sprintf("最大重试次数: %d\n", int(maxRetriesSlider.Value)))
+		updateLog(fmt.Sprintf("首次重试间隔: %.0f秒（之后逐次加倍）\n", retryIntervalSlider.Value))
+		updateLog(fmt.Sprintf("重试总等待上限: %s\n", retryBudgetSelect.Selected))
 
-					results <- res
-				}
-			}() // 移除参数i
+		// 扫描并启动Worker Pool
+		results, err := scheduler.Start(context.Background())
+		if err != nil {
+			dialog.ShowError(err, myWindow)
+			return
+		}
+		running = scheduler
+		for _, short := range scheduler.Snapshot().SpaceShort {
+			updateLog(fmt.Sprintf("⚠️ 目标磁盘空间可能不足: %s\n", short))
 		}
 
-		// 分发任务
-		go func() {
-			for _, f := range files {
-				tasks <- fileutil.Task{
-					Path:     f,
-					SrcRoot:  selectedSrcDir,
-					DestRoot: selectedDestDir,
-					Prefix:   prefixEntry.Text,
-					Suffix:   suffixEntry.Text,
-					Mode:     modeCode,
-				}
-			}
-			close(tasks)
-		}()
-
-		// 等待Worker完成并关闭结果通道
-		go func() {
-			wg.Wait()
-			close(results)
-		}()
+		progressBar.Max = float64(scheduler.Total())
+		progressBar.SetValue(0)
 
 		// 更新UI进度和日志
 		go func() {
-			for res := range results {
-				abortMutex.Lock()
-				shouldAbort := abortFlag
-				abortMutex.Unlock()
-
-				if shouldAbort {
-					continue
-				}
+			successCount := 0
+			skippedCount := 0
+			failedCount := 0
 
+			for res := range results {
 				// 更新统计
-				if res.Err == nil {
+				if res.Err == nil && !res.Skipped {
 					successCount++
 				} else if res.Skipped {
 					skippedCount++
@@ -335,15 +712,32 @@ func Run() {
 					failedCount++
 				}
 
+				// 边扫描边处理时总数随扫描增加，扫描结束前显示为 N+
 				currentProgress := successCount + skippedCount + failedCount
+				total := fmt.Sprint(scheduler.Total())
+				if scheduler.Scanning() {
+					total += "+"
+				}
+				progressBar.Max = float64(scheduler.Total())
 				progressBar.SetValue(float64(currentProgress))
 				updateStats(successCount, skippedCount, failedCount, total)
 
 				// 更新日志
 				status := "成功"
+				if res.Unchanged {
+					status = "未变化"
+				}
+				switch {
+				case res.Conflict == fileutil.ConflictSkipped:
+					status = "跳过（目标已存在）"
+				case res.Skipped && res.Err == nil:
+					status = "跳过（不匹配重命名规则）"
+				case res.Conflict != fileutil.ConflictNone:
+					status += "（" + res.Conflict.Label() + "）"
+				}
 				if res.Err != nil {
 					if res.Skipped {
-						status = "跳过"
+						status = fmt.Sprintf("跳过: %v", res.Err)
 					} else {
 						status = fmt.Sprintf("失败: %v", res.Err)
 					}
@@ -355,47 +749,132 @@ func Run() {
 				}
 
 				verifyStr := ""
-				if res.DstMD5 != "" {
+				if len(res.DstHashes) > 0 {
 					if res.Verified {
 						verifyStr = " | 校验: 一致"
 					} else {
 						verifyStr = " | 校验: 不一致"
 					}
+				} else if res.VerifySkipped {
+					verifyStr = " | 校验: 未要求"
 				}
 
-				updateLog(fmt.Sprintf("[%d/%d] %s -> %s | 源MD5: %s",
-					currentProgress, total, res.OldName, res.NewName, res.SrcMD5))
-				if res.DstMD5 != "" {
-					updateLog(fmt.Sprintf(" 目标MD5: %s%s", res.DstMD5, verifyStr))
+				updateLog(fmt.Sprintf("[%d/%s] %s -> %s | 源 %s",
+					currentProgress, total, res.OldName, res.NewName, fileutil.FormatHashes(res.SrcHashes)))
+				if len(res.DstHashes) > 0 {
+					updateLog(fmt.Sprintf(" 目标 %s%s", fileutil.FormatHashes(res.DstHashes), verifyStr))
+				} else if verifyStr != "" {
+					updateLog(verifyStr)
 				}
 				updateLog(fmt.Sprintf("%s | %s\n", retryInfo, status))
-
-				// 检查是否需要中止
-				if res.Err != nil && !res.Skipped {
-					// 检查错误类型
-					errorInfo := fileutil.AnalyzeError(res.Err, res.OldName)
-					policy := errorHandler.HandleError(errorInfo)
-
-					if policy == fileutil.PolicyAbort {
-						abortMutex.Lock()
-						abortFlag = true
-						abortMutex.Unlock()
-						updateLog("\n⚠️ 检测到严重错误，任务已中止！\n")
-						break
-					}
+				if len(res.MetaErrs) > 0 {
+					updateLog(fmt.Sprintf("  未能保留: %s\n", fileutil.FormatMetaFailures(res.MetaErrs)))
 				}
 			}
 
-			duration := time.Since(startTime)
-			updateLog(fmt.Sprintf("\n任务结束！耗时: %v\n", duration))
+			summary := scheduler.Wait()
+			running = nil
+			if summary.Aborted {
+				updateLog("\n⚠️ 检测到严重错误，任务已中止！\n")
+			} else if summary.Interrupted {
+				updateLog("\n⚠️ 任务已被用户中止！\n")
+			}
+
+			if summary.Manifest != "" {
+				updateLog(fmt.Sprintf("\n校验清单已写入: %s\n", summary.Manifest))
+			} else if summary.ManifestErr != nil {
+				updateLog(fmt.Sprintf("\n写出校验清单失败: %v\n", summary.ManifestErr))
+			}
+			if summary.Deleted > 0 {
+				updateLog(fmt.Sprintf("\n已删除目标目录中的多余文件 %d 个\n", summary.Deleted))
+			}
+			if summary.DeleteErr != nil {
+				updateLog(fmt.Sprintf("\n删除多余文件失败: %v\n", summary.DeleteErr))
+			}
+			if summary.TempCleaned > 0 {
+				updateLog(fmt.Sprintf("\n已清理上次中断遗留的临时文件 %d 个\n", summary.TempCleaned))
+			}
+			if summary.UndoLog != "" {
+				updateLog(fmt.Sprintf("\n撤销日志: %s（可使用 filetool undo 还原）\n", summary.UndoLog))
+			}
+			if summary.UndoErr != nil {
+				updateLog(fmt.Sprintf("\n%v\n", summary.UndoErr))
+			}
+			if summary.Resumed > 0 {
+				updateLog(fmt.Sprintf("\n断点续作：跳过上次已完成的文件 %d 个\n", summary.Resumed))
+			}
+			if summary.JournalErr != nil {
+				updateLog(fmt.Sprintf("\n%v\n", summary.JournalErr))
+			}
+			updateLog(fmt.Sprintf("\n任务结束！耗时: %v\n", summary.Duration))
 
 			// 显示最终统计
-			finalStats := fmt.Sprintf("\n最终统计: 成功 %d, 跳过 %d, 失败 %d / 总计 %d",
-				successCount, skippedCount, failedCount, total)
+			finalStats := fmt.Sprintf("\n最终统计: 成功 %d, 跳过 %d, 失败 %d, 未完成 %d / 总计 %d",
+				summary.Success, summary.Skipped, summary.Failed, summary.Canceled, summary.Total)
+			if summary.Unchanged > 0 {
+				finalStats += fmt.Sprintf("（其中未变化未复制 %d 个）", summary.Unchanged)
+			}
+			if summary.Retries > 0 {
+				finalStats += fmt.Sprintf("\n重试 %d 次，合计等待 %v", summary.Retries, summary.RetryWait.Round(time.Millisecond))
+			}
+			if summary.Trips > 0 {
+				finalStats += fmt.Sprintf("\n熔断 %d 次", summary.Trips)
+			}
+			if summary.BudgetSpent {
+				finalStats += "\n重试预算已用尽，之后失败的文件未再重试"
+			}
+			if len(summary.Conflicts) > 0 {
+				finalStats += fmt.Sprintf("\n目标已存在: %s", fileutil.FormatConflicts(summary.Conflicts))
+			}
+			if len(summary.MetaFailed) > 0 {
+				finalStats += fmt.Sprintf("\n未能保留元数据的文件数: %s", fileutil.FormatMetaCounts(summary.MetaFailed))
+			}
+			if len(summary.Filtered) > 0 {
+				finalStats += fmt.Sprintf("\n扫描筛选排除: %s", fileutil.FormatFiltered(summary.Filtered))
+			}
+			if len(summary.Stranded) > 0 {
+				finalStats += fmt.Sprintf("\n以下文件未能移回原路径，仍在暂存路径中，请手动处理：\n  %s",
+					strings.Join(summary.Stranded, "\n  "))
+			}
 			updateLog(finalStats)
 		}()
 	}
 
+	startProcess := func() {
+		if cfg, ok := buildConfig(); ok {
+			runScheduler(cfg)
+		}
+	}
+
+	// 预览执行计划（不修改文件），确认后严格按预览的计划执行
+	previewProcess := func() {
+		cfg, ok := buildConfig()
+		if !ok {
+			return
+		}
+		plan, err := fileutil.BuildPlan(context.Background(), cfg)
+		if err != nil {
+			dialog.ShowError(err, myWindow)
+			return
+		}
+		preview := dialog.NewCustomConfirm("执行计划预览", "按此计划执行", "取消", newPlanPreview(plan), func(confirmed bool) {
+			if confirmed {
+				cfg.Plan = plan
+				runScheduler(cfg)
+			}
+		}, myWindow)
+		preview.Resize(fyne.NewSize(960, 600))
+		preview.Show()
+	}
+
+	// 中止正在执行的任务（中断进行中的复制和哈希计算）
+	abortProcess := func() {
+		if running != nil {
+			running.Abort()
+			updateLog("\n正在中止任务...\n")
+		}
+	}
+
 	// --- 按钮与布局逻辑 ---
 	// 源目录选择按钮
 	selectSrcBtn := widget.NewButton("选择源文件夹", func() {
@@ -403,10 +882,60 @@ func Run() {
 			if err == nil && list != nil {
 				selectedSrcDir = list.Path()
 				srcPathLabel.SetText(selectedSrcDir)
+				updateRenamePreview()
 			}
 		}, myWindow)
 	})
 
+	// 筛选配置的加载与保存（JSON文件，与命令行的 --filter-file/--save-filter 通用）
+	loadFilterBtn := widget.NewButton("加载筛选配置", func() {
+		dialog.ShowFileOpen(func(r fyne.URIReadCloser, err error) {
+			if err != nil || r == nil {
+				return
+			}
+			r.Close()
+			filter, err := fileutil.LoadFilter(r.URI().Path())
+			if err != nil {
+				dialog.ShowError(err, myWindow)
+				return
+			}
+			showFilter(filter)
+			updateRenamePreview()
+		}, myWindow)
+	})
+	saveFilterBtn := widget.NewButton("保存筛选配置", func() {
+		filter, err := scanFilter()
+		if err != nil {
+			dialog.ShowError(err, myWindow)
+			return
+		}
+		if filter == nil {
+			filter = &fileutil.Filter{}
+		}
+		dialog.ShowFileSave(func(w fyne.URIWriteCloser, err error) {
+			if err != nil || w == nil {
+				return
+			}
+			w.Close()
+			if err := filter.Save(w.URI().Path()); err != nil {
+				dialog.ShowError(err, myWindow)
+			}
+		}, myWindow)
+	})
+
+	// 扫描筛选区域
+	filterGroup := container.NewBorder(
+		widget.NewLabelWithStyle("扫描筛选", fyne.TextAlignLeading, fyne.TextStyle{}),
+		nil, nil, nil,
+		container.NewVBox(
+			container.NewGridWithColumns(2, includeEntry, excludeEntry),
+			container.NewGridWithColumns(2, minSizeEntry, maxSizeEntry),
+			container.NewGridWithColumns(2, afterEntry, beforeEntry),
+			container.NewBorder(nil, nil, skipHiddenCheck, nil, ignoreFileEntry),
+			container.NewHBox(loadFilterBtn, saveFilterBtn),
+		),
+	)
+
 	// 目标目录选择按钮
 	selectDestBtn := widget.NewButton("选择目标文件夹", func() {
 		dialog.ShowFolderOpen(func(list fyne.ListableURI, err error) {
@@ -421,7 +950,12 @@ func Run() {
 	destGroupContent := container.NewBorder(
 		widget.NewLabelWithStyle("目标目录", fyne.TextAlignLeading, fyne.TextStyle{}),
 		nil, nil, nil,
-		container.NewHBox(selectDestBtn, destPathLabel),
+		container.NewVBox(
+			container.NewHBox(selectDestBtn, destPathLabel),
+			verifyCheck,
+			container.NewBorder(nil, nil, widget.NewLabel("保留元数据:"), nil, preserveCheck),
+			syncGroup,
+		),
 	)
 
 	// 异常策略设置区域
@@ -438,10 +972,16 @@ func Run() {
 			maxRetriesSlider,
 		),
 		container.NewBorder(
-			widget.NewLabelWithStyle("重试间隔", fyne.TextAlignLeading, fyne.TextStyle{}),
+			widget.NewLabelWithStyle("首次重试间隔", fyne.TextAlignLeading, fyne.TextStyle{}),
 			retryIntervalLabel, nil, nil,
 			retryIntervalSlider,
 		),
+		container.NewBorder(
+			nil, nil,
+			widget.NewLabelWithStyle("重试总等待上限", fyne.TextAlignLeading, fyne.TextStyle{}),
+			nil,
+			retryBudgetSelect,
+		),
 		widget.NewLabelWithStyle("按错误类型设置策略:", fyne.TextAlignLeading, fyne.TextStyle{}),
 		container.NewScroll(errorPolicyGrid),
 	)
@@ -451,12 +991,36 @@ func Run() {
 		destGroupContainer.RemoveAll()
 		renameGroup.Hide()
 
-		if mode == "复制" || mode == "复制+重命名" || mode == "移动" {
+		op, ok := operatorsByLabel[mode]
+		if !ok {
+			return
+		}
+		if op.Info().Name == "md5" {
+			manifestCheck.Show()
+		} else {
+			manifestCheck.Hide()
+		}
+		if op.Info().NeedsDest {
 			destGroupContainer.Add(destGroupContent)
 		}
-		if mode == "重命名" || mode == "复制+重命名" || mode == "移动" {
+		if op.Info().Name == "sync" {
+			syncGroup.Show()
+		} else {
+			syncGroup.Hide()
+		}
+		if op.Info().Renames {
 			renameGroup.Show()
 		}
+		if op.Info().NeedsDest {
+			spaceGroup.Show()
+		} else {
+			spaceGroup.Hide()
+		}
+		if op.Info().ReadOnly || op.Info().Compares {
+			conflictGroup.Hide()
+		} else {
+			conflictGroup.Show()
+		}
 	}
 	modeRadio.OnChanged = updateUI
 	updateUI(modeRadio.Selected)
@@ -472,6 +1036,7 @@ func Run() {
 			nil, nil, nil,
 			container.NewHBox(selectSrcBtn, srcPathLabel),
 		),
+		filterGroup,
 		widget.NewSeparator(),
 
 		// 操作模式
@@ -481,11 +1046,20 @@ func Run() {
 			modeRadio,
 		),
 
+		// 哈希算法
+		container.NewBorder(
+			widget.NewLabelWithStyle("哈希算法", fyne.TextAlignLeading, fyne.TextStyle{}),
+			nil, nil, nil,
+			container.NewVBox(algorithmCheck, manifestCheck),
+		),
+
 		// 目标目录（动态）
 		destGroupContainer,
 
 		// 重命名设置（动态）
 		renameGroup,
+		conflictGroup,
+		spaceGroup,
 
 		widget.NewSeparator(),
 
@@ -495,6 +1069,7 @@ func Run() {
 			workerCountLabel, nil, nil,
 			workerSlider,
 		),
+		resumeCheck,
 
 		widget.NewSeparator(),
 
@@ -503,12 +1078,16 @@ func Run() {
 
 		widget.NewSeparator(),
 
-		// 执行按钮
-		func() *widget.Button {
-			btn := widget.NewButton("开始执行", startProcess)
-			btn.Importance = widget.HighImportance
-			return btn
-		}(),
+		// 预览/执行/中止按钮
+		container.NewGridWithColumns(3,
+			widget.NewButton("预览计划", previewProcess),
+			func() *widget.Button {
+				btn := widget.NewButton("开始执行", startProcess)
+				btn.Importance = widget.HighImportance
+				return btn
+			}(),
+			widget.NewButton("中止", abortProcess),
+		),
 
 		// 进度条
 		progressBar,
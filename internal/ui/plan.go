@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"training-practice/internal/fileutil"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// planColumns 执行计划预览表的列标题
+var planColumns = []string{"源文件", "目标", "大小", "冲突"}
+
+// newPlanPreview 创建执行计划预览：顶部为统计信息，下方为逐文件的表格
+func newPlanPreview(plan *fileutil.Plan) fyne.CanvasObject {
+	table := widget.NewTable(
+		func() (int, int) {
+			return len(plan.Items), len(planColumns)
+		},
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		func(id widget.TableCellID, obj fyne.CanvasObject) {
+			item := plan.Items[id.Row]
+			label := obj.(*widget.Label)
+			switch id.Col {
+			case 0:
+				label.SetText(item.Source)
+			case 1:
+				if item.Target == "" {
+					label.SetText("-")
+				} else {
+					label.SetText(item.Target)
+				}
+			case 2:
+				label.SetText(fileutil.FormatBytes(item.Size))
+			case 3:
+				var conflicts []string
+				for _, c := range item.Conflicts {
+					conflicts = append(conflicts, c.Label())
+				}
+				label.SetText(strings.Join(conflicts, ", "))
+			}
+		},
+	)
+	table.ShowHeaderRow = true
+	table.CreateHeader = func() fyne.CanvasObject {
+		return widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	}
+	table.UpdateHeader = func(id widget.TableCellID, obj fyne.CanvasObject) {
+		if id.Row < 0 && id.Col >= 0 {
+			obj.(*widget.Label).SetText(planColumns[id.Col])
+		}
+	}
+	table.SetColumnWidth(0, 320)
+	table.SetColumnWidth(1, 320)
+	table.SetColumnWidth(2, 90)
+	table.SetColumnWidth(3, 160)
+
+	text := fmt.Sprintf("文件 %d 个，总大小 %s，有冲突 %d 个（预览不会修改任何文件）",
+		len(plan.Items), fileutil.FormatBytes(plan.TotalBytes), plan.Conflicts)
+	if len(plan.Filtered) > 0 {
+		text += fmt.Sprintf("\n扫描筛选排除: %s", fileutil.FormatFiltered(plan.Filtered))
+	}
+	summary := widget.NewLabel(text)
+	return container.NewBorder(summary, nil, nil, nil, table)
+}